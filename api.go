@@ -4,289 +4,425 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"mime"     //  Multipurpose Internet Mail Extensions (MIME) type detection and extensions
 	"net/http" // HTTP client and server implementations
 	"os"
-	"strconv"
+	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/diorchen/rest-server/internal/authdb"
+	"github.com/diorchen/rest-server/internal/codec"
+	"github.com/diorchen/rest-server/internal/config"
+	"github.com/diorchen/rest-server/internal/feed"
 	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/metrics"
 	"github.com/diorchen/rest-server/internal/middleware"
-
-	"github.com/gorilla/handlers"
-	"github.com/gorilla/mux"
+	"github.com/diorchen/rest-server/internal/notify"
+	"github.com/diorchen/rest-server/internal/problem"
+	"github.com/diorchen/rest-server/internal/tracing"
+	"github.com/diorchen/rest-server/internal/validate"
+	"github.com/diorchen/rest-server/internal/webhook"
 )
 
 type foodServer struct {
 	groceryItemStore *groceryItemStore.GroceryItemStore
+	notifier         *notify.Notifier
+	inbox            *notify.Inbox
+	webhooks         *webhook.Manager
+	feed             *feed.Hub
+	metrics          *metrics.Metrics
+	certificate      *x509.Certificate        // served TLS certificate, checked by /readyz
+	limits           config.Limits            // request size limits, applied by the routes
+	checkOrigin      func(*http.Request) bool // origin check of WebSocket handshakes, same origin if nil
+	wsConns          sync.WaitGroup           // open WebSocket connections
 }
  // Creates a new instance of FoodServer
 func NewFoodServer() *foodServer {
 	store := groceryItemStore.New() 
-	return &foodServer{groceryItemStore: store}
+	return &foodServer{groceryItemStore: store, metrics: metrics.New(store), limits: config.Default().Limits}
 }
 
-// Handles incoming HTTP requests related to food items
-func (fs *foodServer) foodHandler(w http.ResponseWriter, req *http.Request) {
-	if req.URL.Path == "/food/" { 
-		// Request is plain "/food/", without trailing ID.
-		if req.Method == http.MethodPost {
-			fs.createFoodHandler(w, req)
-		} else if req.Method == http.MethodGet {
-			fs.getAllFoodHandler(w, req)
-		} else if req.Method == http.MethodDelete {
-			fs.deleteAllFoodHandler(w, req)
-		} else {
-			http.Error(w, fmt.Sprintf("expect method GET, DELETE or POST at /food/, got %v", req.Method), http.StatusMethodNotAllowed)
-			return
-		}
-	} else {
-		// Request has an ID, as in "/food/<id>".
-		path := strings.Trim(req.URL.Path, "/") // Trims the '/'
-		pathParts := strings.Split(path, "/") // splits the string into parts
-		if len(pathParts) < 2 {
-			http.Error(w, "expect /food/<id> in food handler", http.StatusBadRequest)
-			return
-		}
-		_, err := strconv.Atoi(pathParts[1]) // converts the string into integer
-		if err != nil { // checks if there is an error during this conversion
-			http.Error(w, err.Error(), http.StatusBadRequest) // return error
-			return
-		}
+// Types used to (de-)serialize the request and response of food creation
+// from/to JSON. The validate tags are checked by validate.Struct; they are
+// those of groceryItemStore.FoodItem, which the other APIs check.
+type requestFood struct {
+	Name        string                     `json:"name" validate:"required,max=100"`
+	Description string                     `json:"description" validate:"max=1000"`
+	Ingredients []string                   `json:"ingredients" validate:"unique,max=50,dive,required,max=100"`
+	Expiration  time.Time                  `json:"expiration" validate:"required,within=50"`
+	Nutrition   groceryItemStore.Nutrition `json:"nutrition"`
+	Location    int                        `json:"location" validate:"min=0"`
+	Category    string                     `json:"category" validate:"max=50"`
+}
 
-		if req.Method == http.MethodDelete { // checks the HTTP method and performs the corresponding action
-			fs.deleteFoodHandler(w, req)
-		} else if req.Method == http.MethodGet {
-			fs.getFoodHandler(w, req)
-		} else {
-			http.Error(w, fmt.Sprintf("expect method GET or DELETE at /food/<id>, got %v", req.Method), http.StatusMethodNotAllowed)
-			return
-		}
-	}
+// responseId is the response of handlers creating an item.
+type responseId struct {
+	Id int `json:"id"`
 }
 
 func (fs *foodServer) createFoodHandler(w http.ResponseWriter, req *http.Request) {
-	log.Printf("handling food creation at %s\n", req.URL.Path)
-
-	// Types used internally in this handler to (de-)serialize the request and
-	// response from/to JSON.
-	type requestFood struct {
-		Name        string                     `json:"name"`
-		Description string                     `json:"description"`
-		Ingredients []string                   `json:"ingredients"`
-		Expiration  time.Time                  `json:"expiration"`
-		Nutrition   groceryItemStore.Nutrition `json:"nutrition"`
-	}
-
-	// data structure representing the expected payload format for creating a food item
-	type responseId struct {
-		Id int `json:"id"`
-	}
+	middleware.Logger(req.Context()).Debug("handling food creation")
 
-	// Enforces a JSON Content-Type.
-	contentType := req.Header.Get("Content-Type")
-	mediatype, _, err := mime.ParseMediaType(contentType)
-	if err != nil { // checks for error while parsing the media type
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-	if mediatype != "application/json" { // checks if media type is not equal to JSON
-		http.Error(w, "expect application/json Content-Type", http.StatusUnsupportedMediaType)
+	var rf requestFood // holds the decoded data in 'rf'
+	if !decodeBody(w, req, &rf) || !validateRequest(w, req, rf) {
 		return
 	}
 
-	// Decode the JSON request body into go struct 'requestFood'
-	dec := json.NewDecoder(req.Body)
-	dec.DisallowUnknownFields()
-	var rf requestFood // holds the decoded JSON data in 'rf'
-	if err := dec.Decode(&rf); err != nil { // Checks for error
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	id := fs.groceryItemStore.CreateFood(rf.Name, rf.Description, rf.Ingredients, rf.Expiration, rf.Nutrition)
-	js, err := json.Marshal(responseId{Id: id}) // creates a new struct 'respondID' with 'id' value and marsals it into JSON format
-	if err != nil { // checks for error during JSON marshaling process
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	id, err := fs.groceryItemStore.AddFood(req.Context(), groceryItemStore.FoodItem{
+		Name:        rf.Name,
+		Description: rf.Description,
+		Ingredients: rf.Ingredients,
+		Expiration:  rf.Expiration,
+		Nutrition:   rf.Nutrition,
+		Location:    rf.Location,
+		Category:    rf.Category,
+	})
+	if err != nil {
+		renderError(w, req, err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json") // sets the 'Content-Type' header of the HTTP response to indicate that the response body contains JSON data
-	w.Write(js) // writes the JSON data stored in 'js' as the response body
+	renderJSON(w, responseId{Id: id})
 }
 
 func (fs *foodServer) getAllFoodHandler(w http.ResponseWriter, req *http.Request) {
-	log.Printf("handling get all food items at %s\n", req.URL.Path)
+	middleware.Logger(req.Context()).Debug("handling get all food items")
 
-	allFood := fs.groceryItemStore.GetAllFood()
-	js, err := json.Marshal(allFood)
+	if fs.notModified(w, req) {
+		return
+	}
+	allFood, err := fs.groceryItemStore.GetAllFood(req.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		renderError(w, req, err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(js)
+	render(w, req, allFood)
 }
 
 func (fs *foodServer) getFoodHandler(w http.ResponseWriter, req *http.Request) {
-	id, err := strconv.Atoi(mux.Vars(req)["id"]) // extract ID from URL path and convert to int
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return 
+	id, ok := pathInt(w, req, "id")
+	if !ok {
+		return
 	}
-	log.Printf("handling get food item at %s\n", req.URL.Path)
+	middleware.Logger(req.Context()).Debug("handling get food item")
 
-	food, err := fs.groceryItemStore.GetFood(id)
+	modified := fs.groceryItemStore.LastModified()
+	food, err := fs.groceryItemStore.GetFood(req.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		renderError(w, req, err)
 		return
 	}
-
-	js, err := json.Marshal(food)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if notModifiedSince(w, req, modified) {
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(js)
+
+	render(w, req, food)
 }
 
 func (fs *foodServer) deleteFoodHandler(w http.ResponseWriter, req *http.Request) {
-	log.Printf("handling deletion of food item at %s\n", req.URL.Path)
-	id, err := strconv.Atoi(mux.Vars(req)["id"])
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	middleware.Logger(req.Context()).Debug("handling deletion of food item")
+	id, ok := pathInt(w, req, "id")
+	if !ok {
+		return
 	}
 
-	err = fs.groceryItemStore.DeleteFood(id)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if err := fs.groceryItemStore.DeleteFood(req.Context(), id); err != nil {
+		renderError(w, req, err)
+		return
 	}
 }
 
 func (fs *foodServer) deleteAllFoodHandler(w http.ResponseWriter, req *http.Request) {
-	log.Printf("handling deletion of all foods at %s\n", req.URL.Path)
-	fs.groceryItemStore.DeleteAllFood()
+	middleware.Logger(req.Context()).Debug("handling deletion of all foods")
+	if err := fs.groceryItemStore.DeleteAllFood(req.Context()); err != nil {
+		renderError(w, req, err)
+	}
 }
 
 func (fs *foodServer) ingHandler(w http.ResponseWriter, req *http.Request) {
-	log.Printf("handling foods by ingredients at %s\n", req.URL.Path)
-
-	if req.Method != http.MethodGet {
-		http.Error(w, fmt.Sprintf("expect method GET /ing/<ing>, got %v", req.Method), http.StatusMethodNotAllowed)
-		return
-	}
+	middleware.Logger(req.Context()).Debug("handling foods by ingredients")
 
-	path := strings.Trim(req.URL.Path, "/")
-	pathParts := strings.Split(path, "/")
-	if len(pathParts) < 2 {
-		http.Error(w, "expect /ing/<ingredient> path", http.StatusBadRequest)
+	if fs.notModified(w, req) {
 		return
 	}
-	tag := pathParts[1]
-
-	food := fs.groceryItemStore.GetFoodByIng(tag)
-	js, err := json.Marshal(food)
+	food, err := fs.groceryItemStore.GetFoodByIng(req.Context(), pathString(req, "ing"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		renderError(w, req, err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(js)
+	render(w, req, food)
 }
 
 func (fs *foodServer) expHandler(w http.ResponseWriter, req *http.Request) {
-	log.Printf("handling food items by expiration date at %s\n", req.URL.Path)
+	middleware.Logger(req.Context()).Debug("handling food items by expiration date")
 
-	if req.Method != http.MethodGet {
-		http.Error(w, fmt.Sprintf("expect method GET /exp/<date>, got %v", req.Method), http.StatusMethodNotAllowed)
+	year, ok := pathInt(w, req, "year")
+	if !ok {
 		return
 	}
-
-	path := strings.Trim(req.URL.Path, "/")
-	pathParts := strings.Split(path, "/")
-
-	badRequestError := func() {
-		http.Error(w, fmt.Sprintf("expect /exp/<year>/<month>/<day>, got %v", req.URL.Path), http.StatusBadRequest)
+	month, ok := pathInt(w, req, "month")
+	if !ok {
+		return
 	}
-	if len(pathParts) != 4 {
-		badRequestError()
+	day, ok := pathInt(w, req, "day")
+	if !ok {
 		return
 	}
-
-	year, err := strconv.Atoi(pathParts[1])
-	if err != nil {
-		badRequestError()
+	if month < int(time.January) || month > int(time.December) {
+		renderProblem(w, req, http.StatusBadRequest, problem.CodeBadRequest, fmt.Sprintf("expect month 1 to 12, got %d", month))
 		return
 	}
-	month, err := strconv.Atoi(pathParts[2])
-	if err != nil || month < int(time.January) || month > int(time.December) {
-		badRequestError()
+
+	if fs.notModified(w, req) {
 		return
 	}
-	day, err := strconv.Atoi(pathParts[3])
+	food, err := fs.groceryItemStore.GetFoodsByExpDate(req.Context(), year, time.Month(month), day)
 	if err != nil {
-		badRequestError()
+		renderError(w, req, err)
 		return
 	}
+	render(w, req, food)
+}
+
+// decodeJSON enforces a JSON Content-Type and decodes the request body into v.
+// If that fails, a problem is written to w and false is returned.
+func decodeJSON(w http.ResponseWriter, req *http.Request, v interface{}) bool {
+	contentType := req.Header.Get("Content-Type")
+	mediatype, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediatype != "application/json" { // checks if media type is missing or not equal to JSON
+		renderProblem(w, req, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMediaType, "expect application/json Content-Type")
+		return false
+	}
+
+	dec := json.NewDecoder(req.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		renderError(w, req, decodeError(codec.JSON, err))
+		return false
+	}
+	return true
+}
 
-	food := fs.groceryItemStore.GetFoodsByExpDate(year, time.Month(month), day)
-	js, err := json.Marshal(food)
+// decodeBody decodes the request body into v with the codec matching its
+// Content-Type. If that fails, a problem is written to w and false is returned.
+func decodeBody(w http.ResponseWriter, req *http.Request, v interface{}) bool {
+	c := codec.ForContentType(req.Header.Get("Content-Type"))
+	if c == nil {
+		renderProblem(w, req, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMediaType,
+			"expect Content-Type "+strings.Join(codec.ContentTypes(), ", "))
+		return false
+	}
+	// Read the body whole, so that a body over the size limit is reported as
+	// such whatever the codec does with read errors.
+	body, err := io.ReadAll(req.Body)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		renderError(w, req, err)
+		return false
+	}
+	if err := c.Decode(bytes.NewReader(body), v); err != nil {
+		renderError(w, req, decodeError(c, err))
+		return false
+	}
+	return true
+}
+
+// validateRequest checks v against the rules in its validate tags. If any is
+// violated, a validation problem listing all the field errors is written to w
+// and false is returned.
+func validateRequest(w http.ResponseWriter, req *http.Request, v interface{}) bool {
+	if err := validate.Struct(v); err != nil {
+		renderError(w, req, err)
+		return false
+	}
+	return true
+}
+
+// renderJSON renders 'v' as JSON and writes it as a response into w.
+func renderJSON(w http.ResponseWriter, v interface{}) {
+	js, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("rendering response", "type", fmt.Sprintf("%T", v), "error", err)
+		problem.Write(w, nil, problem.New(http.StatusInternalServerError, problem.CodeInternal, ""))
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(js)
 }
 
+// render writes v to w in the representation of the API version of req and the
+// format negotiated from its Accept header: JSON, YAML, MessagePack or CSV.
+func render(w http.ResponseWriter, req *http.Request, v interface{}) {
+	v = represent(requestVersion(req), v)
+	w.Header().Add("Vary", "Accept")
+	c := codec.Negotiate(req.Header.Get("Accept"))
+	if c == nil {
+		renderProblem(w, req, http.StatusNotAcceptable, problem.CodeNotAcceptable,
+			"expect Accept to allow "+strings.Join(codec.ContentTypes(), ", "))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := c.Encode(&buf, v); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	w.Header().Set("Content-Type", c.ContentType())
+	w.Write(buf.Bytes())
+}
+
+// runEvery calls fn every interval until ctx is done.
+func runEvery(ctx context.Context, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn()
+		}
+	}
+}
+
+// fatal logs err and exits, for errors the server can't start or keep running
+// with.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
 func main() {
-	certFile := flag.String("certfile", "cert.pem", "certificate PEM file")
-	keyFile := flag.String("keyfile", "key.pem", "key PEM file")
-	flag.Parse()
+	cfg, printConfig, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if printConfig {
+		if err := cfg.WriteYAML(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	router := mux.NewRouter()
-	router.StrictSlash(true)
-	server := NewFoodServer() // Creates new instance of FoodServer
-	
-
-	router.Handle("/food/", middleware.BasicAuth(http.HandlerFunc(server.createFoodHandler))).Methods("POST")
-	router.HandleFunc("/food/", server.getAllFoodHandler).Methods("GET")
-	router.HandleFunc("/food/", server.deleteAllFoodHandler).Methods("DELETE")
-	router.HandleFunc("/food/{id:[0-9]+}/", server.deleteFoodHandler).Methods("DELETE")
-	router.HandleFunc("/food/{id:[0-9]+}/", server.getFoodHandler).Methods("GET")
-	router.HandleFunc("/ing/{ing}/", server.ingHandler).Methods("GET")
-	router.HandleFunc("/exp/{year:[0-9]+}/{month:[0-9]+}/{day:[0-9]+}/", server.expHandler).Methods("GET")
-
-	// router.HandleFunc("/food/", server.foodHandler)
-	// router.HandleFunc("/ing/", server.ingHandler)
-	// router.HandleFunc("/exp/", server.expHandler)
-
-	// Set up logging and panic recovery middleware.
-	router.Use(func(h http.Handler) http.Handler {
-		return handlers.LoggingHandler(os.Stdout, h)
+	// Log structured records; the standard logger, still used by some
+	// packages, writes through the same handler.
+	logger, err := middleware.NewLogger(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Trace.Exporter,
+		Endpoint:    cfg.Trace.Endpoint,
+		SampleRatio: cfg.Trace.SampleRatio,
+		Stdout:      os.Stdout,
 	})
-	router.Use(handlers.RecoveryHandler(handlers.PrintRecoveryStack(true)))
+	if err != nil {
+		fatal("setting up tracing", err)
+	}
+
+	server := NewFoodServer() // Creates new instance of FoodServer
+
+	// Load the certificate up front, so that /readyz can check its validity.
+	cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+	if err != nil {
+		fatal("loading certificate", err)
+	}
+	server.certificate, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		fatal("parsing certificate", err)
+	}
+
+	// Set up expiration notifications; the inbox sink is always on so users can
+	// read their notifications through the API.
+	server.inbox = notify.NewInbox(100)
+	sinks := []notify.Sink{server.inbox}
+	if cfg.Notify.Log {
+		sinks = append(sinks, notify.LogSink{})
+	}
+	if cfg.Notify.Webhook != "" {
+		sinks = append(sinks, notify.NewWebhookSink(cfg.Notify.Webhook))
+	}
+	server.notifier = notify.New(server.groceryItemStore, authdb.Users(), cfg.Notify.LeadHours, sinks...)
+	if cfg.Notify.SMTP != "" {
+		server.notifier.AddSink(&notify.SMTPSink{Addr: cfg.Notify.SMTP, From: cfg.Notify.From, Email: server.notifier.Email})
+	}
+	background, stopBackground := context.WithCancel(context.Background())
+	go server.notifier.Run(background, time.Duration(cfg.Notify.Interval))
+
+	// Deliver store events, including food expiring, to webhook subscribers.
+	server.webhooks = webhook.New(webhook.DefaultOptions)
+	server.groceryItemStore.Subscribe(server.webhooks.HandleEvent)
+	go runEvery(background, time.Duration(cfg.Notify.Interval), server.groceryItemStore.ReportExpired)
+
+	server.feed = feed.NewHub(cfg.Events.Buffer)
+	server.groceryItemStore.Subscribe(server.feed.Publish)
+
+	handler, err := newHandler(server, cfg, logger)
+	if err != nil {
+		fatal("setting up routes", err)
+	}
 
-	addr := "localhost:8080"
 	srv := &http.Server{
-		Addr: 	addr,
-		Handler: router,
+		Addr: 	cfg.Listen,
+		Handler: handler,
+		ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+		ReadHeaderTimeout: time.Duration(cfg.Timeouts.ReadHeader),
+		ReadTimeout: time.Duration(cfg.Timeouts.Read),
+		WriteTimeout: time.Duration(cfg.Timeouts.Write),
+		IdleTimeout: time.Duration(cfg.Timeouts.Idle),
+		MaxHeaderBytes: cfg.Limits.HeaderBytes,
 		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert},
 			MinVersion:	tls.VersionTLS13,
 			PreferServerCipherSuites: true,
 		},
 	}
 
-	log.Printf("Starting server on %s", addr)
-	// http.ListenAndServe("localhost:8080", router)
-	log.Fatal(srv.ListenAndServeTLS(*certFile, *keyFile))
-
+	// Serve until SIGINT or SIGTERM, then shut down gracefully. A second
+	// signal kills the server right away.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	served := make(chan error, 1)
+	go func() {
+		slog.Info("starting server", "addr", cfg.Listen)
+		served <- srv.ListenAndServeTLS("", "")
+	}()
+	select {
+	case err := <-served:
+		fatal("serving", err)
+	case <-ctx.Done():
+		stop()
+	}
 
+	slog.Info("shutting down", "timeout", time.Duration(cfg.Timeouts.Shutdown).String())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeouts.Shutdown))
+	defer cancel()
+	if err := server.shutdown(ctx, srv, stopBackground); err != nil {
+		slog.Warn("unclean shutdown", "error", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("flushing spans", "error", err)
+	}
+	slog.Info("server stopped")
 }
 
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diorchen/rest-server/internal/authdb"
+	"github.com/diorchen/rest-server/internal/config"
+	"github.com/diorchen/rest-server/internal/feed"
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/notify"
+	"github.com/diorchen/rest-server/internal/openapi"
+	"github.com/diorchen/rest-server/internal/problem"
+	"github.com/diorchen/rest-server/internal/webhook"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard) // handlers log every request
+	os.Exit(m.Run())
+}
+
+// newTestServer returns a server with an empty store and the router of main,
+// without the background notification and webhook delivery.
+func newTestServer(t *testing.T) (*foodServer, http.Handler) {
+	t.Helper()
+	server := NewFoodServer()
+	server.inbox = notify.NewInbox(10)
+	server.notifier = notify.New(server.groceryItemStore, authdb.Users(), []int{24}, server.inbox)
+	server.webhooks = webhook.New(webhook.DefaultOptions)
+	t.Cleanup(server.webhooks.Close)
+	server.feed = feed.NewHub(16)
+	server.groceryItemStore.Subscribe(server.feed.Publish)
+
+	router, err := newRouter(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, router
+}
+
+// request is a request to a test server. Requests with a body are sent as
+// JSON unless contentType says otherwise.
+type request struct {
+	method      string
+	path        string
+	body        string
+	contentType string
+	accept      string
+	auth        bool // authenticate as joe
+}
+
+func serve(h http.Handler, r request) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(r.method, r.path, strings.NewReader(r.body))
+	if r.body != "" {
+		if r.contentType == "" {
+			r.contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", r.contentType)
+	}
+	if r.accept != "" {
+		req.Header.Set("Accept", r.accept)
+	}
+	if r.auth {
+		req.SetBasicAuth("joe", "1234")
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+// decode decodes the JSON body of rr into v.
+func decode(t *testing.T, rr *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rr.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %q: %v", rr.Body.String(), err)
+	}
+}
+
+// wantProblem checks that rr is a problem response with the given status and
+// code.
+func wantProblem(t *testing.T, rr *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if rr.Code != status {
+		t.Errorf("got status %d, want %d; body %s", rr.Code, status, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != problem.ContentType {
+		t.Errorf("got Content-Type %q, want %q", ct, problem.ContentType)
+		return
+	}
+	var p problem.Problem
+	decode(t, rr, &p)
+	if p.Code != code {
+		t.Errorf("got code %q, want %q", p.Code, code)
+	}
+}
+
+var (
+	label      = time.Date(2030, 7, 1, 0, 0, 0, 0, time.UTC)
+	futureFood = `{"name": "Milk", "ingredients": ["Milk"], "expiration": "2030-07-01T00:00:00Z"}`
+)
+
+func TestFoodHandlers(t *testing.T) {
+	server, h := newTestServer(t)
+
+	rr := serve(h, request{method: "POST", path: "/v1/food/", body: futureFood, auth: true})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create: got %d %s", rr.Code, rr.Body.String())
+	}
+	var created responseId
+	decode(t, rr, &created)
+	server.groceryItemStore.CreateFood(context.Background(), "Bread", "", []string{"Flour", "Water"}, label.AddDate(0, 0, 1), groceryItemStore.Nutrition{})
+
+	var food groceryItemStore.FoodItem
+	rr = serve(h, request{method: "GET", path: "/v1/food/0/"})
+	decode(t, rr, &food)
+	if food.Id != created.Id || food.Name != "Milk" || !food.Expiration.Equal(label) {
+		t.Errorf("get: got %+v", food)
+	}
+
+	lists := map[string]int{
+		"/v1/food/":         2,
+		"/v1/ing/Flour/":    1,
+		"/v1/ing/Sugar/":    0,
+		"/v1/exp/2030/7/1/": 1,
+		"/v1/exp/2030/7/2/": 1,
+		"/v1/exp/2030/8/1/": 0,
+	}
+	for path, want := range lists {
+		var foods []groceryItemStore.FoodItem
+		rr := serve(h, request{method: "GET", path: path})
+		if rr.Code != http.StatusOK {
+			t.Errorf("%s: got %d %s", path, rr.Code, rr.Body.String())
+			continue
+		}
+		decode(t, rr, &foods)
+		if len(foods) != want {
+			t.Errorf("%s: got %d food items, want %d", path, len(foods), want)
+		}
+	}
+
+	if rr := serve(h, request{method: "DELETE", path: "/v1/food/0/"}); rr.Code != http.StatusOK {
+		t.Errorf("delete: got %d", rr.Code)
+	}
+	wantProblem(t, serve(h, request{method: "DELETE", path: "/v1/food/0/"}), http.StatusNotFound, problem.CodeNotFound)
+	wantProblem(t, serve(h, request{method: "GET", path: "/v1/food/0/"}), http.StatusNotFound, problem.CodeNotFound)
+
+	if rr := serve(h, request{method: "DELETE", path: "/v1/food/"}); rr.Code != http.StatusOK {
+		t.Errorf("delete all: got %d", rr.Code)
+	}
+	if food, _ := server.groceryItemStore.GetAllFood(context.Background()); len(food) != 0 {
+		t.Errorf("got %d food items after deleting all", len(food))
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	_, h := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		req    request
+		status int
+		code   string
+	}{
+		{"unknown route", request{method: "GET", path: "/v1/groceries/"}, http.StatusNotFound, problem.CodeNotFound},
+		{"non-numeric id", request{method: "GET", path: "/v1/food/milk/"}, http.StatusNotFound, problem.CodeNotFound},
+		{"id overflowing int", request{method: "GET", path: "/v1/food/99999999999999999999/"}, http.StatusNotFound, problem.CodeNotFound},
+		{"wrong method", request{method: "PUT", path: "/v1/food/"}, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed},
+		{"month out of range", request{method: "GET", path: "/v1/exp/2030/13/1/"}, http.StatusBadRequest, problem.CodeBadRequest},
+		{"no credentials", request{method: "POST", path: "/v1/food/", body: futureFood}, http.StatusUnauthorized, problem.CodeUnauthorized},
+		{"malformed JSON", request{method: "POST", path: "/v1/food/", body: `{"name": `, auth: true}, http.StatusBadRequest, problem.CodeInvalidJSON},
+		{"unknown field", request{method: "POST", path: "/v1/food/", body: `{"colour": "white"}`, auth: true}, http.StatusBadRequest, problem.CodeInvalidJSON},
+		{"invalid food", request{method: "POST", path: "/v1/food/", body: `{"name": ""}`, auth: true}, http.StatusBadRequest, problem.CodeValidation},
+		{"missing location", request{method: "POST", path: "/v1/food/", body: `{"name": "Milk", "expiration": "2030-07-01T00:00:00Z", "location": 9}`, auth: true}, http.StatusBadRequest, problem.CodeValidation},
+		{"unsupported body", request{method: "POST", path: "/v1/food/", body: "Milk", contentType: "text/plain", auth: true}, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMediaType},
+		{"unacceptable", request{method: "GET", path: "/v1/food/", accept: "image/png"}, http.StatusNotAcceptable, problem.CodeNotAcceptable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantProblem(t, serve(h, tt.req), tt.status, tt.code)
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	server, _ := newTestServer(t)
+	cfg := config.Default()
+	cfg.Limits.BodyBytes = 32
+	cfg.Limits.ImportBodyBytes = 16
+	cfg.Timeouts.Request = config.Duration(10 * time.Millisecond)
+	cfg.RateLimit.Write = config.Policy{Requests: 2, Period: config.Duration(time.Hour)}
+	h, err := newHandler(server, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	large := `{"name": "` + strings.Repeat("Milk", 10) + `"}`
+	wantProblem(t, serve(h, request{method: "POST", path: "/v1/food/", body: large, auth: true}),
+		http.StatusRequestEntityTooLarge, problem.CodeBodyTooLarge)
+	wantProblem(t, serve(h, request{method: "POST", path: "/v1/food/", body: "name: " + strings.Repeat("Milk", 10), contentType: "application/yaml", auth: true}),
+		http.StatusRequestEntityTooLarge, problem.CodeBodyTooLarge)
+	wantProblem(t, serve(h, request{method: "POST", path: "/v1/import", body: `{"version": 1, "items": []}`, auth: true}),
+		http.StatusRequestEntityTooLarge, problem.CodeBodyTooLarge)
+	// The posts of food took the write limit; imports have a limit of their own.
+	wantProblem(t, serve(h, request{method: "POST", path: "/v1/food/", body: `{}`, auth: true}),
+		http.StatusTooManyRequests, problem.CodeRateLimited)
+
+	// Requests give up waiting for a busy store.
+	server.groceryItemStore.Lock()
+	defer server.groceryItemStore.Unlock()
+	wantProblem(t, serve(h, request{method: "GET", path: "/v1/food/0/"}), http.StatusServiceUnavailable, problem.CodeTimeout)
+}
+
+func TestFormats(t *testing.T) {
+	_, h := newTestServer(t)
+
+	csv := "name,ingredients,expiration\nBread,Flour;Water,2030-07-01T00:00:00Z\n"
+	if rr := serve(h, request{method: "POST", path: "/v1/food/", body: csv, contentType: "text/csv", auth: true}); rr.Code != http.StatusOK {
+		t.Fatalf("create from CSV: got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr := serve(h, request{method: "GET", path: "/v1/food/0/", accept: "application/yaml"})
+	if ct := rr.Header().Get("Content-Type"); ct != "application/yaml" {
+		t.Errorf("got Content-Type %q, want application/yaml", ct)
+	}
+	if !strings.Contains(rr.Body.String(), "- Flour\n") {
+		t.Errorf("got YAML %q, want the ingredients listed", rr.Body.String())
+	}
+	if vary := rr.Header().Get("Vary"); vary != "Accept" {
+		t.Errorf("got Vary %q, want Accept", vary)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	server, h := newTestServer(t)
+	fridge, _ := server.groceryItemStore.CreateLocation(context.Background(), "Fridge", groceryItemStore.KindFridge)
+	server.groceryItemStore.AddFood(context.Background(), groceryItemStore.FoodItem{Name: "Milk", Ingredients: []string{"Milk"}, Expiration: label, Location: fridge})
+
+	// The unversioned routes are v1, with deprecation headers.
+	rr := serve(h, request{method: "GET", path: "/ing/Milk/"})
+	var v1 []groceryItemStore.FoodItem
+	decode(t, rr, &v1)
+	if len(v1) != 1 || v1[0].Location != fridge {
+		t.Errorf("got %+v, want the milk in v1", v1)
+	}
+	if rr.Header().Get("Deprecation") == "" || rr.Header().Get("Sunset") == "" {
+		t.Errorf("got headers %v, want Deprecation and Sunset", rr.Header())
+	}
+	if link := rr.Header().Get("Link"); link != `</v1/ing/Milk/>; rel="successor-version"` {
+		t.Errorf("got Link %q", link)
+	}
+
+	rr = serve(h, request{method: "GET", path: "/v1/ing/Milk/"})
+	if rr.Header().Get("Deprecation") != "" {
+		t.Error("v1 is deprecated, want current")
+	}
+
+	rr = serve(h, request{method: "GET", path: "/v2/food/0/"})
+	var v2 foodV2
+	decode(t, rr, &v2)
+	if v2.Name != "Milk" || !v2.Expires.Label.Equal(label) || v2.Storage.Location == nil || *v2.Storage.Location != fridge {
+		t.Errorf("got %+v, want the milk in v2", v2)
+	}
+	if !strings.Contains(serve(h, request{method: "GET", path: "/v2/food/"}).Body.String(), `"storage"`) {
+		t.Error("v2 list isn't in the v2 representation")
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	_, h := newTestServer(t)
+
+	rr := serve(h, request{method: "GET", path: "/openapi.json"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got %d %s", rr.Code, rr.Body.String())
+	}
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	decode(t, rr, &doc)
+	for _, path := range []string{"/food/", "/v1/food/{id}/", "/v2/exp/{year}/{month}/{day}/", "/graphql", "/metrics"} {
+		if doc.Paths[path] == nil {
+			t.Errorf("path %s missing", path)
+		}
+	}
+}
+
+func TestOpenAPIStale(t *testing.T) {
+	apiOperations["GET /gone/"] = openapi.Operation{Summary: "A route that was removed"}
+	defer delete(apiOperations, "GET /gone/")
+
+	if _, err := newRouter(NewFoodServer()); err == nil || !strings.Contains(err.Error(), "GET /gone/") {
+		t.Errorf("got %v, want an error naming the stale operation", err)
+	}
+}
@@ -0,0 +1,42 @@
+// Conditional requests for responses read from the store. Clients may keep
+// them, but must revalidate them with If-Modified-Since, which the server
+// answers from the modification time of the store.
+
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// notModified sets the caching headers of a response read from the store, and
+// answers 304 Not Modified if the client's copy is still current, in which
+// case the handler is done. It must be called before reading the store, so
+// that the response is at least as recent as its Last-Modified.
+func (fs *foodServer) notModified(w http.ResponseWriter, req *http.Request) bool {
+	return notModifiedSince(w, req, fs.groceryItemStore.LastModified())
+}
+
+// notModifiedSince is notModified for a store last modified at modified. It's
+// for responses about a single resource, which may not exist: their handlers
+// take the modification time before reading the store, and only call
+// notModifiedSince once the read succeeded, so a missing resource is a 404.
+func notModifiedSince(w http.ResponseWriter, req *http.Request, modified time.Time) bool {
+	w.Header().Set("Cache-Control", "no-cache")
+
+	// Last-Modified has a resolution of seconds, so a change later within the
+	// same second would go unnoticed; it's only sent once that second is over.
+	modified = modified.Truncate(time.Second)
+	if !modified.Before(time.Now().Truncate(time.Second)) {
+		return false
+	}
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil || modified.After(since) {
+		return false
+	}
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
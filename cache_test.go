@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	_, h := newTestServer(t)
+	get := func(path, since string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if since != "" {
+			req.Header.Set("If-Modified-Since", since)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Within the second of the last change, there's no Last-Modified to
+	// revalidate with.
+	if rr := serve(h, request{method: "POST", path: "/v1/food/", body: futureFood, auth: true}); rr.Code != http.StatusOK {
+		t.Fatalf("create: got %d", rr.Code)
+	}
+	rr := get("/v1/food/", "")
+	if rr.Header().Get("Last-Modified") != "" || rr.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("got headers %v, want no-cache without Last-Modified", rr.Header())
+	}
+
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	rr = get("/v1/food/", "")
+	modified := rr.Header().Get("Last-Modified")
+	if rr.Code != http.StatusOK || modified == "" {
+		t.Fatalf("got status %d with headers %v, want 200 with Last-Modified", rr.Code, rr.Header())
+	}
+	for _, path := range []string{"/v1/food/", "/v1/food/0/", "/v1/ing/Milk/"} {
+		if rr := get(path, modified); rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+			t.Errorf("%s: got status %d with body %q, want 304", path, rr.Code, rr.Body.String())
+		}
+	}
+	// Copies of resources that don't exist aren't current.
+	for _, path := range []string{"/v1/food/9999/", "/v1/food/9999/history/", "/v1/locations/9999/", "/v1/locations/9999/food/"} {
+		if rr := get(path, modified); rr.Code != http.StatusNotFound {
+			t.Errorf("%s: got status %d, want 404", path, rr.Code)
+		}
+	}
+
+	// A change makes copies stale.
+	serve(h, request{method: "POST", path: "/v1/food/", body: futureFood, auth: true})
+	if rr := get("/v1/food/", modified); rr.Code != http.StatusOK {
+		t.Errorf("after a change: got status %d, want 200", rr.Code)
+	}
+}
@@ -0,0 +1,75 @@
+// Rendering of errors as RFC 7807 problem+json responses.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/diorchen/rest-server/internal/codec"
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/middleware"
+	"github.com/diorchen/rest-server/internal/problem"
+	"github.com/diorchen/rest-server/internal/validate"
+)
+
+// renderProblem writes a problem with the given status, code and detail to w.
+func renderProblem(w http.ResponseWriter, req *http.Request, status int, code string, detail string) {
+	problem.Write(w, req, problem.New(status, code, detail))
+}
+
+// renderError writes err to w as a problem, with the status and code following
+// from its type. Errors of unknown types are internal; they are logged, and
+// not shown to the client.
+func renderError(w http.ResponseWriter, req *http.Request, err error) {
+	var (
+		p         *problem.Problem
+		fieldErrs validate.Errors
+		notFound  *groceryItemStore.NotFoundError
+		conflict  *groceryItemStore.ConflictError
+		invalid   *groceryItemStore.ValidationError
+		tooLarge  *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &p):
+	case errors.As(err, &fieldErrs):
+		p = problem.New(http.StatusBadRequest, problem.CodeValidation, "the request has invalid fields")
+		p.Errors = fieldErrs
+	case errors.As(err, &notFound):
+		p = problem.New(http.StatusNotFound, problem.CodeNotFound, err.Error())
+	case errors.As(err, &conflict):
+		p = problem.New(http.StatusConflict, problem.CodeConflict, err.Error())
+	case errors.As(err, &invalid):
+		p = problem.New(http.StatusBadRequest, problem.CodeValidation, err.Error())
+	case errors.As(err, &tooLarge):
+		p = bodyTooLarge(tooLarge)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		// The request timed out, or the client went away, while waiting.
+		p = problem.New(http.StatusServiceUnavailable, problem.CodeTimeout, "the request took too long, try again later")
+	default:
+		middleware.Logger(req.Context()).Error("internal error", "error", err)
+		p = problem.New(http.StatusInternalServerError, problem.CodeInternal, "")
+	}
+	problem.Write(w, req, p)
+}
+
+// decodeError describes an error of decoding a request body with c without
+// the decoder's internals.
+func decodeError(c codec.Codec, err error) *problem.Problem {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return bodyTooLarge(tooLarge)
+	}
+	if c != codec.JSON {
+		return problem.New(http.StatusBadRequest, problem.CodeInvalidBody, codec.Describe(c, err))
+	}
+	return problem.New(http.StatusBadRequest, problem.CodeInvalidJSON, codec.Describe(c, err))
+}
+
+// bodyTooLarge describes a request body cut off by http.MaxBytesReader.
+func bodyTooLarge(err *http.MaxBytesError) *problem.Problem {
+	return problem.New(http.StatusRequestEntityTooLarge, problem.CodeBodyTooLarge,
+		fmt.Sprintf("request body is larger than %d bytes", err.Limit))
+}
@@ -0,0 +1,94 @@
+// Server-Sent Events stream of every change to the store.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/middleware"
+	"github.com/diorchen/rest-server/internal/problem"
+)
+
+// sseHeartbeat is how often a comment is sent on idle streams, so proxies don't
+// time them out.
+const sseHeartbeat = 15 * time.Second
+
+func (fs *foodServer) eventsHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling event stream")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		renderProblem(w, req, http.StatusInternalServerError, problem.CodeInternal, "streaming unsupported")
+		return
+	}
+
+	// Browsers resume with the Last-Event-ID header; allow a query parameter
+	// too for clients that can't set headers.
+	lastIdStr := req.Header.Get("Last-Event-ID")
+	if lastIdStr == "" {
+		lastIdStr = req.URL.Query().Get("lastEventId")
+	}
+	lastId := 0
+	if lastIdStr != "" {
+		var err error
+		if lastId, err = strconv.Atoi(lastIdStr); err != nil {
+			renderProblem(w, req, http.StatusBadRequest, problem.CodeBadRequest, fmt.Sprintf("expect numeric Last-Event-ID, got %q", lastIdStr))
+			return
+		}
+	}
+
+	sub, replay, complete := fs.feed.Subscribe(lastId, 64)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if !complete {
+		// Some events were lost; tell the client to refetch everything.
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, ev := range replay {
+		if err := writeSSE(w, ev); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case ev, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind or shutting down; the client
+				// reconnects and resumes from the last id it got.
+				return
+			}
+			if err := writeSSE(w, ev); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeSSE writes ev as a single Server-Sent Event.
+func writeSSE(w http.ResponseWriter, ev groceryItemStore.Event) error {
+	js, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.Id, ev.Type, js)
+	return err
+}
@@ -0,0 +1,153 @@
+// Handlers for exporting the whole inventory as a versioned archive and
+// importing such archives, e.g. to move to another server.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/diorchen/rest-server/internal/authdb"
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/middleware"
+	"github.com/diorchen/rest-server/internal/notify"
+	"github.com/diorchen/rest-server/internal/problem"
+)
+
+// archiveVersion is the version of the archive format; it changes whenever
+// the format does, and /import only accepts archives of this version.
+const archiveVersion = 1
+
+// archive is the body of /export responses and /import requests. IDs are those
+// of the exporting server.
+type archive struct {
+	Version   int                                     `json:"version"`
+	Metadata  archiveMetadata                         `json:"metadata"`
+	Items     []groceryItemStore.FoodItem             `json:"items"`
+	Locations []groceryItemStore.Location             `json:"locations"`
+	Rules     []groceryItemStore.ShelfLifeRule        `json:"rules"`
+	History   map[int][]groceryItemStore.HistoryEntry `json:"history,omitempty"` // move history by item ID
+	Users     []archiveUser                           `json:"users"`
+}
+
+// archiveMetadata describes where an archive comes from; imports ignore it.
+type archiveMetadata struct {
+	ExportedAt time.Time `json:"exportedAt"`
+	ExportedBy string    `json:"exportedBy"`
+	APIVersion string    `json:"apiVersion"`
+}
+
+// archiveUser holds the settings of one user. Passwords aren't exported.
+type archiveUser struct {
+	Name          string             `json:"name"`
+	Notifications notify.Preferences `json:"notifications"`
+}
+
+// responseImport reports what an import did, or would do for a dry run.
+type responseImport struct {
+	groceryItemStore.ImportReport
+	UsersUpdated int      `json:"usersUpdated"`
+	UsersSkipped []string `json:"usersSkipped,omitempty"` // users of the archive unknown to this server
+}
+
+func (fs *foodServer) exportHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling export")
+
+	a, err := fs.groceryItemStore.Export(req.Context())
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	exported := archive{
+		Version: archiveVersion,
+		Metadata: archiveMetadata{
+			ExportedAt: time.Now().UTC(),
+			ExportedBy: requestUser(req),
+			APIVersion: apiInfo.Version,
+		},
+		Items:     a.Food,
+		Locations: a.Locations,
+		Rules:     a.Rules,
+		History:   a.History,
+	}
+	for _, user := range authdb.Users() {
+		exported.Users = append(exported.Users, archiveUser{Name: user, Notifications: fs.notifier.GetPreferences(user)})
+	}
+
+	filename := fmt.Sprintf("groceries-%s.json", exported.Metadata.ExportedAt.Format("20060102-150405"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	renderJSON(w, exported)
+}
+
+func (fs *foodServer) importHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling import")
+
+	query := req.URL.Query()
+	mode := groceryItemStore.ImportMerge
+	if m := query.Get("mode"); m != "" {
+		mode = groceryItemStore.ImportMode(m)
+	}
+	dryRun := false
+	if s := query.Get("dryRun"); s != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(s); err != nil {
+			renderProblem(w, req, http.StatusBadRequest, problem.CodeBadRequest, "expect dryRun to be true or false")
+			return
+		}
+	}
+
+	var a archive
+	if !decodeJSON(w, req, &a) {
+		return
+	}
+	if a.Version != archiveVersion {
+		renderProblem(w, req, http.StatusBadRequest, problem.CodeValidation,
+			fmt.Sprintf("unsupported archive version %d, expect %d", a.Version, archiveVersion))
+		return
+	}
+
+	// Check the users before importing anything, so a bad archive changes
+	// nothing at all.
+	known := map[string]bool{}
+	for _, user := range authdb.Users() {
+		known[user] = true
+	}
+	var resp responseImport
+	var users []archiveUser
+	for _, u := range a.Users {
+		if err := u.Notifications.Validate(); err != nil {
+			renderProblem(w, req, http.StatusBadRequest, problem.CodeValidation, fmt.Sprintf("user %q: %v", u.Name, err))
+			return
+		}
+		if !known[u.Name] {
+			resp.UsersSkipped = append(resp.UsersSkipped, u.Name)
+			continue
+		}
+		users = append(users, u)
+	}
+
+	report, err := fs.groceryItemStore.Import(req.Context(), groceryItemStore.Archive{
+		Food:      a.Items,
+		Locations: a.Locations,
+		Rules:     a.Rules,
+		History:   a.History,
+	}, mode, dryRun)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	resp.ImportReport = report
+	resp.UsersUpdated = len(users)
+
+	if !dryRun {
+		for _, u := range users {
+			if err := fs.notifier.SetPreferences(u.Name, u.Notifications); err != nil {
+				renderError(w, req, err)
+				return
+			}
+		}
+	}
+	renderJSON(w, resp)
+}
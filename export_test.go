@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/problem"
+)
+
+func TestExportImport(t *testing.T) {
+	src, h := newTestServer(t)
+	fridge, _ := src.groceryItemStore.CreateLocation(context.Background(), "Fridge", groceryItemStore.KindFridge)
+	src.groceryItemStore.AddFood(context.Background(), groceryItemStore.FoodItem{Name: "Milk", Expiration: label, Location: fridge})
+
+	rr := serve(h, request{method: "GET", path: "/v1/export", auth: true})
+	if rr.Header().Get("Content-Disposition") == "" {
+		t.Error("export isn't sent as an attachment")
+	}
+	exported := rr.Body.String()
+	var a archive
+	decode(t, rr, &a)
+	if a.Version != archiveVersion || len(a.Items) != 1 || len(a.Locations) != 1 || len(a.Users) == 0 {
+		t.Fatalf("got archive %+v", a)
+	}
+
+	dst, h := newTestServer(t)
+	dst.groceryItemStore.CreateFood(context.Background(), "Bread", "", nil, label, groceryItemStore.Nutrition{})
+
+	var report responseImport
+	decode(t, serve(h, request{method: "POST", path: "/v1/import?mode=replace&dryRun=true", body: exported, auth: true}), &report)
+	if !report.DryRun || report.FoodDeleted != 1 || report.FoodCreated != 1 {
+		t.Errorf("got dry run report %+v", report)
+	}
+	if food, _ := dst.groceryItemStore.GetAllFood(context.Background()); len(food) != 1 || food[0].Name != "Bread" {
+		t.Fatalf("dry run changed the store: %+v", food)
+	}
+
+	decode(t, serve(h, request{method: "POST", path: "/v1/import", body: exported, auth: true}), &report)
+	if report.Mode != groceryItemStore.ImportMerge || report.FoodCreated != 1 || report.LocationsCreated != 1 {
+		t.Errorf("got merge report %+v", report)
+	}
+	milk, err := dst.groceryItemStore.GetFood(context.Background(), report.FoodIds[0])
+	if err != nil || milk.Name != "Milk" || milk.Location != report.LocationIds[fridge] {
+		t.Errorf("got %+v, %v; want the milk in the imported fridge", milk, err)
+	}
+
+	wantProblem(t, serve(h, request{method: "POST", path: "/v1/import", body: `{"version": 2}`, auth: true}),
+		http.StatusBadRequest, problem.CodeValidation)
+	wantProblem(t, serve(h, request{method: "POST", path: "/v1/import?mode=append", body: exported, auth: true}),
+		http.StatusBadRequest, problem.CodeValidation)
+}
@@ -0,0 +1,119 @@
+// Probes for orchestrators and build information. These routes need no
+// authentication and are left out of the access log.
+
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/diorchen/rest-server/internal/middleware"
+)
+
+// buildTime is when the binary was built, set with
+// -ldflags "-X main.buildTime=...".
+var buildTime string
+
+// Statuses of readiness checks.
+const (
+	checkOK     = "ok"
+	checkFailed = "failed"
+)
+
+type responseCheck struct {
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type responseReady struct {
+	Status string                   `json:"status"` // "ready" or "unready"
+	Checks map[string]responseCheck `json:"checks"`
+}
+
+type responseVersion struct {
+	Version    string `json:"version"`              // module version, "(devel)" for local builds
+	Revision   string `json:"revision,omitempty"`   // VCS revision the binary was built from
+	Modified   bool   `json:"modified,omitempty"`   // whether the working tree had local changes
+	CommitTime string `json:"commitTime,omitempty"` // time of the revision
+	BuildTime  string `json:"buildTime,omitempty"`
+	GoVersion  string `json:"goVersion"`
+}
+
+// healthHandler answers liveness probes: the process is up and serving HTTP.
+func (fs *foodServer) healthHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling health check")
+	renderJSON(w, responseCheck{Status: checkOK})
+}
+
+// readyHandler answers readiness probes. It checks that the store can serve
+// requests and that the TLS certificate is valid, and responds 503 if either
+// isn't.
+func (fs *foodServer) readyHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling readiness check")
+	resp := responseReady{Status: "ready", Checks: make(map[string]responseCheck)}
+
+	resp.Checks["store"] = responseCheck{Status: checkOK}
+	if err := fs.groceryItemStore.Ready(req.Context()); err != nil {
+		resp.Checks["store"] = responseCheck{Status: checkFailed, Detail: err.Error()}
+	}
+	if fs.certificate != nil {
+		resp.Checks["certificate"] = checkCertificate(fs.certificate, time.Now())
+	}
+
+	status := http.StatusOK
+	for name, check := range resp.Checks {
+		if check.Status != checkOK {
+			middleware.Logger(req.Context()).Warn("not ready", "check", name, "detail", check.Detail)
+			resp.Status = "unready"
+			status = http.StatusServiceUnavailable
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// checkCertificate checks that cert is valid at now.
+func checkCertificate(cert *x509.Certificate, now time.Time) responseCheck {
+	switch {
+	case now.Before(cert.NotBefore):
+		return responseCheck{Status: checkFailed, Detail: fmt.Sprintf("not valid before %s", cert.NotBefore.Format(time.RFC3339))}
+	case now.After(cert.NotAfter):
+		return responseCheck{Status: checkFailed, Detail: fmt.Sprintf("expired %s", cert.NotAfter.Format(time.RFC3339))}
+	}
+	return responseCheck{Status: checkOK, Detail: fmt.Sprintf("expires %s", cert.NotAfter.Format(time.RFC3339))}
+}
+
+// versionHandler reports the version of the binary, from the build
+// information embedded by the Go toolchain.
+func (fs *foodServer) versionHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling get version")
+	renderJSON(w, readVersion())
+}
+
+func readVersion() responseVersion {
+	v := responseVersion{Version: "unknown", BuildTime: buildTime, GoVersion: runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return v
+	}
+	v.Version = info.Main.Version
+	v.GoVersion = info.GoVersion
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			v.Revision = s.Value
+		case "vcs.time":
+			v.CommitTime = s.Value
+		case "vcs.modified":
+			v.Modified = s.Value == "true"
+		}
+	}
+	return v
+}
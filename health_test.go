@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diorchen/rest-server/internal/config"
+)
+
+func TestHealth(t *testing.T) {
+	server, h := newTestServer(t)
+
+	if rr := serve(h, request{method: "GET", path: "/healthz"}); rr.Code != http.StatusOK {
+		t.Errorf("healthz: got %d", rr.Code)
+	}
+
+	server.certificate = &x509.Certificate{NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour)}
+	rr := serve(h, request{method: "GET", path: "/readyz"})
+	var ready responseReady
+	decode(t, rr, &ready)
+	if rr.Code != http.StatusOK || ready.Status != "ready" || ready.Checks["store"].Status != checkOK || ready.Checks["certificate"].Status != checkOK {
+		t.Errorf("readyz: got %d %+v", rr.Code, ready)
+	}
+
+	server.certificate.NotAfter = time.Now().Add(-time.Minute)
+	rr = serve(h, request{method: "GET", path: "/readyz"})
+	decode(t, rr, &ready)
+	if rr.Code != http.StatusServiceUnavailable || ready.Status != "unready" || ready.Checks["certificate"].Status != checkFailed {
+		t.Errorf("readyz with expired certificate: got %d %+v", rr.Code, ready)
+	}
+
+	var version responseVersion
+	decode(t, serve(h, request{method: "GET", path: "/version"}), &version)
+	if version.Version == "" || version.GoVersion == "" {
+		t.Errorf("version: got %+v", version)
+	}
+}
+
+func TestProbesNotLogged(t *testing.T) {
+	server, _ := newTestServer(t)
+	server.certificate = &x509.Certificate{NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour)}
+	var log bytes.Buffer
+	h, err := newHandler(server, config.Default(), slog.New(slog.NewTextHandler(&log, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/healthz", "/readyz", "/version"} {
+		if rr := serve(h, request{method: "GET", path: path}); rr.Code != http.StatusOK {
+			t.Errorf("%s: got %d", path, rr.Code)
+		}
+	}
+	if log.Len() != 0 {
+		t.Errorf("probes were logged: %s", log.String())
+	}
+	serve(h, request{method: "GET", path: "/v1/food/"})
+	if !strings.Contains(log.String(), "/v1/food/") {
+		t.Errorf("got log %q, want a record of /v1/food/", log.String())
+	}
+}
@@ -2,7 +2,11 @@
 
 package authdb
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"sort"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 var usersPasswords = map[string][]byte{
 	"joe":  []byte("$2a$12$aMfFQpGSiPiYkekov7LOsu63pZFaWzmlfm1T8lvG6JFj2Bh4SZPWS"),
@@ -20,4 +24,14 @@ func VerifyUserPass(username, password string) bool {
 		return true
 	}
 	return false
-}
\ No newline at end of file
+}
+
+// Users returns the names of all the users in the database, sorted.
+func Users() []string {
+	users := make([]string, 0, len(usersPasswords))
+	for user := range usersPasswords {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	return users
+}
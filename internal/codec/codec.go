@@ -0,0 +1,290 @@
+// Encodings of request and response bodies, and negotiation between them.
+//
+// JSON is the canonical encoding. YAML and MessagePack use the same field names
+// as JSON; CSV flattens structs into columns, one row per item, and quotes
+// strings that spreadsheets would run as formulas.
+
+package codec
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// Codec encodes and decodes values in one media type.
+type Codec interface {
+	ContentType() string
+	Encode(w io.Writer, v interface{}) error
+	Decode(r io.Reader, v interface{}) error
+}
+
+// The supported codecs.
+var (
+	JSON    Codec = jsonCodec{}
+	YAML    Codec = yamlCodec{}
+	MsgPack Codec = msgpackCodec{}
+	CSV     Codec = csvCodec{}
+)
+
+// All lists the codecs in order of preference.
+var All = []Codec{JSON, YAML, MsgPack, CSV}
+
+// aliases are other media types in use for the codecs.
+var aliases = map[string]Codec{
+	"application/x-yaml":      YAML,
+	"text/yaml":               YAML,
+	"application/x-msgpack":   MsgPack,
+	"application/vnd.msgpack": MsgPack,
+}
+
+// ForContentType returns the codec of a Content-Type header value, or nil if
+// the media type isn't supported.
+func ForContentType(contentType string) Codec {
+	mediatype, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	return byMediaType(mediatype)
+}
+
+func byMediaType(mediatype string) Codec {
+	for _, c := range All {
+		if c.ContentType() == mediatype {
+			return c
+		}
+	}
+	return aliases[mediatype]
+}
+
+// Negotiate picks the codec best matching an Accept header value, preferring
+// JSON on ties. Each codec gets the quality of the most specific media range
+// matching it. An empty header accepts JSON. It returns nil if none of the
+// codecs is acceptable.
+func Negotiate(accept string) Codec {
+	if strings.TrimSpace(accept) == "" {
+		return JSON
+	}
+
+	type mediaRange struct {
+		mediatype string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediatype, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qs, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediatype, q})
+	}
+
+	var best Codec
+	bestQ := 0.0
+	for _, c := range All {
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			if spec := matches(r.mediatype, c); spec > specificity {
+				q, specificity = r.q, spec
+			}
+		}
+		if q > bestQ {
+			best, bestQ = c, q
+		}
+	}
+	return best
+}
+
+// matches returns how specifically the media range accepts c: 2 for
+// type/subtype, 1 for type/* and 0 for */*, or -1 if it doesn't.
+func matches(mediarange string, c Codec) int {
+	switch {
+	case mediarange == "*/*":
+		return 0
+	case strings.HasSuffix(mediarange, "/*"):
+		if strings.HasPrefix(c.ContentType(), strings.TrimSuffix(mediarange, "*")) {
+			return 1
+		}
+	case byMediaType(mediarange) == c:
+		return 2
+	}
+	return -1
+}
+
+// ContentTypes returns the media types of all codecs, sorted.
+func ContentTypes() []string {
+	types := make([]string, len(All))
+	for i, c := range All {
+		types[i] = c.ContentType()
+	}
+	sort.Strings(types)
+	return types
+}
+
+// names are the names of the codecs for clients.
+var names = map[Codec]string{JSON: "JSON", YAML: "YAML", MsgPack: "MessagePack", CSV: "CSV"}
+
+// yamlLine finds the line in YAML syntax errors.
+var yamlLine = regexp.MustCompile(`^yaml: line ([0-9]+):`)
+
+// Describe describes an error of decoding a body with c for the client, in
+// terms of c rather than of the decoders: the position of syntax errors, and
+// the fields of type errors.
+func Describe(c Codec, err error) string {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		timeErr   *time.ParseError
+		csvErr    *csv.ParseError
+	)
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return "request body is empty or truncated"
+	case errors.As(err, &typeErr) && c == JSON:
+		return fmt.Sprintf("expect %s for field %q, got JSON %s", typeErr.Type, typeErr.Field, typeErr.Value)
+	case errors.As(err, &typeErr):
+		// YAML and MessagePack go through JSON, so their values have types
+		// of JSON.
+		return fmt.Sprintf("expect %s for field %q", typeErr.Type, typeErr.Field)
+	case errors.As(err, &timeErr):
+		return fmt.Sprintf("expect RFC 3339 time, got %q", timeErr.Value)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return strings.TrimPrefix(err.Error(), "json: ")
+	case errors.As(err, &syntaxErr) && c == JSON:
+		return fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset)
+	case errors.As(err, &csvErr):
+		return fmt.Sprintf("malformed CSV at line %d", csvErr.Line)
+	case c == CSV && strings.HasPrefix(err.Error(), "csv: "):
+		// The CSV codec's own errors describe the cells at fault.
+		return strings.TrimPrefix(err.Error(), "csv: ")
+	}
+	if m := yamlLine.FindStringSubmatch(err.Error()); m != nil && c == YAML {
+		return "malformed YAML at line " + m[1]
+	}
+	return "malformed " + names[c]
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return "application/json" }
+
+func (jsonCodec) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (jsonCodec) Decode(r io.Reader, v interface{}) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// toGeneric converts v to the maps, slices and scalars its JSON encoding
+// decodes to, so other encodings use the field names and value formats of JSON.
+// Integral numbers become integers rather than floats.
+func toGeneric(v interface{}) (interface{}, error) {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return numbers(generic), nil
+}
+
+// numbers replaces the json.Numbers in generic by int64 or float64 values.
+func numbers(generic interface{}) interface{} {
+	switch g := generic.(type) {
+	case json.Number:
+		if n, err := g.Int64(); err == nil {
+			return n
+		}
+		f, _ := g.Float64()
+		return f
+	case map[string]interface{}:
+		for k, v := range g {
+			g[k] = numbers(v)
+		}
+	case []interface{}:
+		for i, v := range g {
+			g[i] = numbers(v)
+		}
+	}
+	return generic
+}
+
+// fromGeneric stores a decoded generic value into v by way of JSON.
+func fromGeneric(generic interface{}, v interface{}) error {
+	js, err := json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	return JSON.Decode(bytes.NewReader(js), v)
+}
+
+// YAML and MessagePack go through JSON, so field names and formats of values
+// such as times are the same in all of them.
+type yamlCodec struct{}
+
+func (yamlCodec) ContentType() string { return "application/yaml" }
+
+func (yamlCodec) Encode(w io.Writer, v interface{}) error {
+	generic, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	if err := enc.Encode(generic); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func (yamlCodec) Decode(r io.Reader, v interface{}) error {
+	var generic interface{}
+	if err := yaml.NewDecoder(r).Decode(&generic); err != nil {
+		return err
+	}
+	return fromGeneric(generic, v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string { return "application/msgpack" }
+
+func (msgpackCodec) Encode(w io.Writer, v interface{}) error {
+	generic, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	return msgpack.NewEncoder(w).Encode(generic)
+}
+
+func (msgpackCodec) Decode(r io.Reader, v interface{}) error {
+	var generic interface{}
+	if err := msgpack.NewDecoder(r).Decode(&generic); err != nil {
+		return err
+	}
+	return fromGeneric(generic, v)
+}
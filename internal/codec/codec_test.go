@@ -0,0 +1,181 @@
+package codec
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type nutrition struct {
+	Calories int     `json:"calories"`
+	Fat      float64 `json:"fat"`
+}
+
+type food struct {
+	Id          int        `json:"id"`
+	Name        string     `json:"name"`
+	Ingredients []string   `json:"ingredients"`
+	Expiration  time.Time  `json:"expiration"`
+	Nutrition   nutrition  `json:"nutrition"`
+	Opened      *time.Time `json:"opened,omitempty"`
+	Secret      string     `json:"-"`
+}
+
+var testFood = []food{
+	{Id: 1, Name: "Milk, whole", Ingredients: []string{"Milk"}, Expiration: time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition: nutrition{Calories: 42, Fat: 3.5}},
+	{Id: 2, Name: "Bread", Ingredients: []string{"Flour", "Water"}},
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   Codec
+	}{
+		{"", JSON},
+		{"*/*", JSON},
+		{"text/csv", CSV},
+		{"application/x-yaml", YAML},
+		{"text/html, application/msgpack;q=0.9, */*;q=0.1", MsgPack},
+		{"application/yaml;q=0.5, text/csv", CSV},
+		{"text/*", CSV},
+		{"application/json;q=0, application/*", YAML},
+		{"image/png", nil},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.accept); got != tt.want {
+			t.Errorf("Negotiate(%q) = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestForContentType(t *testing.T) {
+	if c := ForContentType("application/json; charset=utf-8"); c != JSON {
+		t.Errorf("got %v, want JSON", c)
+	}
+	if c := ForContentType("text/plain"); c != nil {
+		t.Errorf("got %v for text/plain, want nil", c)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, c := range All {
+		t.Run(c.ContentType(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := c.Encode(&buf, testFood); err != nil {
+				t.Fatal(err)
+			}
+			var got []food
+			if err := c.Decode(&buf, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, testFood) {
+				t.Errorf("got %+v, want %+v", got, testFood)
+			}
+		})
+	}
+}
+
+func TestCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := CSV.Encode(&buf, testFood); err != nil {
+		t.Fatal(err)
+	}
+	want := `id,name,ingredients,expiration,nutrition.calories,nutrition.fat,opened
+1,"Milk, whole",Milk,2023-07-01T00:00:00Z,42,3.5,
+2,Bread,Flour;Water,,0,0,
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	var one food
+	if err := CSV.Decode(strings.NewReader("name,nutrition.fat\nCheese,30\n"), &one); err != nil {
+		t.Fatal(err)
+	}
+	if one.Name != "Cheese" || one.Nutrition.Fat != 30 {
+		t.Errorf("got %+v", one)
+	}
+
+	if err := CSV.Decode(strings.NewReader("name,colour\nCheese,yellow\n"), &one); err == nil {
+		t.Error("unknown column, got no error")
+	}
+	if err := CSV.Decode(strings.NewReader("name\nCheese\nMilk\n"), &one); err == nil {
+		t.Error("two rows into one struct, got no error")
+	}
+}
+
+func TestCSVFormulas(t *testing.T) {
+	items := []food{{Name: "=HYPERLINK(\"http://evil\")", Ingredients: []string{"@SUM(A1)", "Milk"}}, {Name: "'+1"}}
+	var buf bytes.Buffer
+	if err := CSV.Encode(&buf, items); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `'=HYPERLINK`) || !strings.Contains(buf.String(), `'@SUM(A1);Milk`) || !strings.Contains(buf.String(), `''+1`) {
+		t.Errorf("got\n%s\nwant formulas prefixed", buf.String())
+	}
+
+	var got []food
+	if err := CSV.Decode(&buf, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Name != items[0].Name || got[0].Ingredients[0] != "@SUM(A1)" || got[1].Name != "'+1" {
+		t.Errorf("got %+v, want %+v", got, items)
+	}
+}
+
+func TestIntegers(t *testing.T) {
+	var buf bytes.Buffer
+	if err := MsgPack.Encode(&buf, testFood[0]); err != nil {
+		t.Fatal(err)
+	}
+	var generic map[string]interface{}
+	if err := msgpack.NewDecoder(&buf).Decode(&generic); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := generic["id"].(float64); ok {
+		t.Errorf("got id %#v, want an integer", generic["id"])
+	}
+
+	buf.Reset()
+	if err := YAML.Encode(&buf, testFood[0]); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "id: 1\n") || !strings.Contains(buf.String(), "fat: 3.5\n") {
+		t.Errorf("got\n%s", buf.String())
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		codec Codec
+		body  string
+		want  string
+	}{
+		{JSON, `{"name": `, "request body is empty or truncated"},
+		{JSON, `{"name": 1}`, `expect string for field "name", got JSON number`},
+		{JSON, `{"colour": "white"}`, `unknown field "colour"`},
+		{JSON, `{"expiration": "soon"}`, `expect RFC 3339 time, got "soon"`},
+		{JSON, `{"name" 1}`, "malformed JSON at offset 9"},
+		{YAML, "name: [", "malformed YAML at line 1"},
+		{YAML, "name: [1]", `expect string for field "name"`},
+		{YAML, "colour: white", `unknown field "colour"`},
+		{MsgPack, "\xc1", "malformed MessagePack"},
+		{CSV, "name\n\"Milk", "malformed CSV at line 2"},
+		{CSV, "id\nmany", `row 1, column "id": expect integer, got "many"`},
+	}
+	for _, tt := range tests {
+		var f food
+		err := tt.codec.Decode(strings.NewReader(tt.body), &f)
+		if err == nil {
+			t.Errorf("%s %q: got no error", tt.codec.ContentType(), tt.body)
+			continue
+		}
+		if got := Describe(tt.codec, err); got != tt.want {
+			t.Errorf("%s %q: got %q, want %q", tt.codec.ContentType(), tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+// CSV encoding of structs and slices of structs.
+
+package codec
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listSeparator joins the items of slice fields within one CSV cell.
+const listSeparator = ";"
+
+// formulaPrefix is put before strings that spreadsheets would take for a
+// formula, so they're shown as text instead of run. Decoding takes it off.
+const formulaPrefix = "'"
+
+// needsPrefix reports whether s looks like a formula, or like a string with
+// the prefix, which gets another one so that decoding restores it.
+func needsPrefix(s string) bool {
+	if rest, ok := strings.CutPrefix(s, formulaPrefix); ok {
+		return needsPrefix(rest)
+	}
+	return s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0]))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// column is a CSV column holding a possibly nested field of a struct.
+type column struct {
+	name  string
+	index []int
+}
+
+// columns flattens the fields of struct type t; nested structs contribute one
+// column per field, named like "nutrition.calories".
+func columns(t reflect.Type, prefix string, index []int) []column {
+	var cols []column
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		idx := append(append([]int(nil), index...), i)
+		if f.Type.Kind() == reflect.Struct && f.Type != timeType {
+			cols = append(cols, columns(f.Type, prefix+name+".", idx)...)
+			continue
+		}
+		cols = append(cols, column{name: prefix + name, index: idx})
+	}
+	return cols
+}
+
+// csvCodec writes a header row and a row per item; single structs are one row.
+type csvCodec struct{}
+
+func (csvCodec) ContentType() string { return "text/csv" }
+
+func (csvCodec) Encode(w io.Writer, v interface{}) error {
+	val := reflect.Indirect(reflect.ValueOf(v))
+	rows := val
+	if val.Kind() == reflect.Struct {
+		rows = reflect.Append(reflect.MakeSlice(reflect.SliceOf(val.Type()), 0, 1), val)
+	}
+	if rows.Kind() != reflect.Slice && rows.Kind() != reflect.Array || rows.Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("csv: can't encode %T, expect struct or slice of structs", v)
+	}
+
+	cols := columns(rows.Type().Elem(), "", nil)
+	cw := csv.NewWriter(w)
+	header := make([]string, len(cols))
+	for i, col := range cols {
+		header[i] = col.name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(cols))
+	for i := 0; i < rows.Len(); i++ {
+		for j, col := range cols {
+			record[j] = formatCell(rows.Index(i).FieldByIndex(col.index))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Decode reads a header row and data rows into a struct, which takes exactly
+// one row, or a slice of structs. Columns may be in any order and missing
+// columns leave their fields zero.
+func (csvCodec) Decode(r io.Reader, v interface{}) error {
+	ptr := reflect.ValueOf(v)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() {
+		return fmt.Errorf("csv: decode needs a non-nil pointer, got %T", v)
+	}
+	target := ptr.Elem()
+	elemType := target.Type()
+	if target.Kind() == reflect.Slice {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("csv: can't decode into %T, expect struct or slice of structs", v)
+	}
+
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return io.EOF
+	}
+
+	byName := map[string]column{}
+	for _, col := range columns(elemType, "", nil) {
+		byName[col.name] = col
+	}
+	header := make([]column, len(records[0]))
+	for i, name := range records[0] {
+		col, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return fmt.Errorf("csv: unknown column %q", name)
+		}
+		header[i] = col
+	}
+
+	rows := records[1:]
+	if target.Kind() == reflect.Struct && len(rows) != 1 {
+		return fmt.Errorf("csv: expect one data row, got %d", len(rows))
+	}
+	for n, record := range rows {
+		item := reflect.New(elemType).Elem()
+		for i, cell := range record {
+			if err := parseCell(item.FieldByIndex(header[i].index), cell); err != nil {
+				return fmt.Errorf("csv: row %d, column %q: %v", n+1, header[i].name, err)
+			}
+		}
+		if target.Kind() == reflect.Struct {
+			target.Set(item)
+		} else {
+			target.Set(reflect.Append(target, item))
+		}
+	}
+	return nil
+}
+
+func formatCell(v reflect.Value) string {
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return ""
+		}
+		return formatCell(v.Elem())
+	case reflect.Slice, reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatCell(v.Index(i))
+		}
+		return strings.Join(items, listSeparator)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.String:
+		if s := v.String(); needsPrefix(s) {
+			return formulaPrefix + s
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func parseCell(v reflect.Value, cell string) error {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		return nil
+	}
+	if v.Type() == timeType {
+		t, err := time.Parse(time.RFC3339, cell)
+		if err != nil {
+			return fmt.Errorf("expect RFC 3339 time, got %q", cell)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := parseCell(elem.Elem(), cell); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Slice:
+		parts := strings.Split(cell, listSeparator)
+		items := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := parseCell(items.Index(i), part); err != nil {
+				return err
+			}
+		}
+		v.Set(items)
+	case reflect.String:
+		if s, ok := strings.CutPrefix(cell, formulaPrefix); ok && needsPrefix(s) {
+			cell = s
+		}
+		v.SetString(cell)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return fmt.Errorf("expect boolean, got %q", cell)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(cell, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expect integer, got %q", cell)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(cell, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expect non-negative integer, got %q", cell)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(cell, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expect number, got %q", cell)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
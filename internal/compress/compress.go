@@ -0,0 +1,235 @@
+// Compression of responses with zstd, brotli or gzip, negotiated from the
+// Accept-Encoding header of requests.
+//
+// Only textual responses of at least minSize bytes are compressed; the start
+// of every response is buffered until that is known. Event streams and
+// WebSocket upgrades are left alone.
+
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// minSize is the size under which responses aren't worth compressing.
+const minSize = 1024
+
+// encoder is the interface shared by the compressors of all encodings.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoding is a content coding with a pool of its encoders, which are
+// expensive to create.
+type encoding struct {
+	name string
+	pool sync.Pool
+}
+
+// encodings lists the supported codings in order of preference, for clients
+// accepting several equally. The levels favor speed, since every response is
+// compressed anew.
+var encodings = []*encoding{
+	{name: "zstd", pool: sync.Pool{New: func() interface{} {
+		enc, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+		return enc
+	}}},
+	{name: "br", pool: sync.Pool{New: func() interface{} {
+		return brotli.NewWriterLevel(nil, 4)
+	}}},
+	{name: "gzip", pool: sync.Pool{New: func() interface{} {
+		return gzip.NewWriter(nil)
+	}}},
+}
+
+// negotiate picks the encoding to respond with from an Accept-Encoding
+// header, or nil for none.
+func negotiate(accept string) *encoding {
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		qualities[name] = q
+	}
+
+	var best *encoding
+	bestQ := 0.0
+	for _, enc := range encodings {
+		q, ok := qualities[enc.name]
+		if !ok {
+			q = qualities["*"]
+		}
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// compressible reports whether responses of contentType are worth
+// compressing: text, but not event streams, which are written a few bytes at
+// a time.
+func compressible(contentType string) bool {
+	mediatype, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediatype == "text/event-stream" {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediatype, "text/"),
+		strings.HasSuffix(mediatype, "+json"),
+		mediatype == "application/json",
+		mediatype == "application/yaml",
+		mediatype == "application/javascript",
+		mediatype == "application/xml":
+		return true
+	}
+	return false
+}
+
+// Middleware compresses the responses of next with the best encoding the
+// client accepts.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		enc := negotiate(req.Header.Get("Accept-Encoding"))
+		if enc == nil || req.Method == http.MethodHead || req.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		cw := &writer{ResponseWriter: w, encoding: enc}
+		defer cw.close()
+		next.ServeHTTP(cw, req)
+	})
+}
+
+// writer compresses the response written to it, once it has seen enough of
+// it to tell whether to.
+type writer struct {
+	http.ResponseWriter
+	encoding *encoding
+
+	status  int     // status to write once decided
+	buf     []byte  // start of the body, while undecided
+	decided bool    // whether the status and headers were written
+	enc     encoder // nil unless compressing
+}
+
+func (w *writer) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+	if status < 200 {
+		// Informational responses precede the actual one.
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if !w.decided {
+		if !compressible(w.Header().Get("Content-Type")) || w.Header().Get("Content-Encoding") != "" {
+			w.decide(false)
+		} else {
+			w.buf = append(w.buf, b...)
+			if len(w.buf) >= minSize {
+				if err := w.start(); err != nil {
+					return 0, err
+				}
+			}
+			return len(b), nil
+		}
+	}
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// decide writes the status and headers, compressed or not.
+func (w *writer) decide(compress bool) {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if compress {
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Encoding", w.encoding.name)
+		w.enc = w.encoding.pool.Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// start starts compressing with the buffered start of the body.
+func (w *writer) start() error {
+	w.decide(true)
+	_, err := w.enc.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// Flush sends what was written so far. A flush commits to compressing
+// compressible responses, however short.
+func (w *writer) Flush() {
+	if !w.decided {
+		if len(w.buf) > 0 {
+			w.start()
+		} else {
+			w.decide(false)
+		}
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// close finishes the response: it writes short bodies uncompressed, and ends
+// the compressed stream of the others.
+func (w *writer) close() {
+	if !w.decided {
+		w.decide(false)
+		if len(w.buf) > 0 {
+			w.ResponseWriter.Write(w.buf)
+		}
+		return
+	}
+	if w.enc != nil {
+		w.enc.Close()
+		w.enc.Reset(nil)
+		w.encoding.pool.Put(w.enc)
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
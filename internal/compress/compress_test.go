@@ -0,0 +1,95 @@
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br", "br"},
+		{"gzip, deflate, br, zstd", "zstd"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"zstd;q=0, *", "br"},
+		{"*;q=0", ""},
+		{"GZIP;q=0.8, compress", "gzip"},
+	}
+	for _, tt := range tests {
+		got := ""
+		if enc := negotiate(tt.accept); enc != nil {
+			got = enc.name
+		}
+		if got != tt.want {
+			t.Errorf("Accept-Encoding %q: got %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	large := `[` + strings.Repeat(`{"name":"Milk"},`, 100) + `{}]`
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", req.URL.Query().Get("type"))
+		io.WriteString(w, req.URL.Query().Get("body"))
+	}))
+	decoders := map[string]func(io.Reader) io.Reader{
+		"gzip": func(r io.Reader) io.Reader { zr, _ := gzip.NewReader(r); return zr },
+		"br":   func(r io.Reader) io.Reader { return brotli.NewReader(r) },
+		"zstd": func(r io.Reader) io.Reader { zr, _ := zstd.NewReader(r); return zr },
+	}
+
+	for name, decode := range decoders {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/?type=application/json&body="+large, nil)
+			req.Header.Set("Accept-Encoding", name)
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if got := rr.Header().Get("Content-Encoding"); got != name {
+				t.Fatalf("got Content-Encoding %q", got)
+			}
+			if rr.Body.Len() >= len(large) {
+				t.Errorf("got %d bytes, want fewer than %d", rr.Body.Len(), len(large))
+			}
+			body, err := io.ReadAll(decode(rr.Body))
+			if err != nil || string(body) != large {
+				t.Errorf("got body %.40q, error %v", body, err)
+			}
+		})
+	}
+
+	uncompressed := []struct {
+		name  string
+		query string
+	}{
+		{"small", "type=application/json&body=[]"},
+		{"binary", "type=application/msgpack&body=" + large},
+		{"event stream", "type=text/event-stream&body=" + large},
+	}
+	for _, tt := range uncompressed {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/?"+tt.query, nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			if rr.Header().Get("Content-Encoding") != "" || rr.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("got headers %v, want no encoding but Vary", rr.Header())
+			}
+			if !strings.Contains(tt.query, rr.Body.String()) {
+				t.Errorf("got body %.40q", rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,514 @@
+// Configuration of the server.
+//
+// The configuration is layered: the defaults are overridden by a YAML or TOML
+// config file, which is overridden by environment variables, which are
+// overridden by command line flags. Environment variables are named after the
+// keys of the file, prefixed with GROCERY_: tls.certFile is GROCERY_TLS_CERT_FILE.
+// Lists are comma separated in environment variables and flags.
+
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/diorchen/rest-server/internal/tracing"
+)
+
+// EnvPrefix starts the names of the environment variables read by Load.
+const EnvPrefix = "GROCERY_"
+
+// Backends of authentication and storage.
+const (
+	AuthStatic    = "static" // the built-in users of authdb
+	StorageMemory = "memory" // the in-memory GroceryItemStore
+)
+
+// Config is the whole configuration of the server.
+type Config struct {
+	Listen    string    `yaml:"listen" toml:"listen"` // host:port to serve on
+	TLS       TLS       `yaml:"tls" toml:"tls"`
+	Auth      Auth      `yaml:"auth" toml:"auth"`
+	Storage   Storage   `yaml:"storage" toml:"storage"`
+	Timeouts  Timeouts  `yaml:"timeouts" toml:"timeouts"`
+	Limits    Limits    `yaml:"limits" toml:"limits"`
+	RateLimit RateLimit `yaml:"rateLimit" toml:"rateLimit"`
+	CORS      CORS      `yaml:"cors" toml:"cors"`
+	Log       Log       `yaml:"log" toml:"log"`
+	Trace     Trace     `yaml:"trace" toml:"trace"`
+	Notify    Notify    `yaml:"notify" toml:"notify"`
+	Events    Events    `yaml:"events" toml:"events"`
+}
+
+type TLS struct {
+	CertFile string `yaml:"certFile" toml:"certFile"` // certificate PEM file
+	KeyFile  string `yaml:"keyFile" toml:"keyFile"`   // key PEM file
+}
+
+type Auth struct {
+	Backend string `yaml:"backend" toml:"backend"` // only AuthStatic for now
+}
+
+type Storage struct {
+	Backend string `yaml:"backend" toml:"backend"` // only StorageMemory for now
+}
+
+// Timeouts bound how long clients and requests may take. Event streams,
+// WebSockets and gRPC are exempt from all but Idle and Shutdown.
+type Timeouts struct {
+	ReadHeader Duration `yaml:"readHeader" toml:"readHeader"` // to read the request line and headers
+	Read       Duration `yaml:"read" toml:"read"`             // to read the whole request, body included
+	Write      Duration `yaml:"write" toml:"write"`           // from the end of the headers to the end of the response
+	Idle       Duration `yaml:"idle" toml:"idle"`             // keep-alive connections wait for the next request
+	Request    Duration `yaml:"request" toml:"request"`       // handlers and store operations give up after
+	Shutdown   Duration `yaml:"shutdown" toml:"shutdown"`     // wait for requests in flight when shutting down
+}
+
+// Limits bound the size of requests.
+type Limits struct {
+	HeaderBytes     int   `yaml:"headerBytes" toml:"headerBytes"`         // request line and headers
+	BodyBytes       int64 `yaml:"bodyBytes" toml:"bodyBytes"`             // request bodies, except imports
+	ImportBodyBytes int64 `yaml:"importBodyBytes" toml:"importBodyBytes"` // archives posted to /import
+}
+
+// RateLimit configures the rate limits of requests, counted per user, or per
+// client address for anonymous requests. Read applies to GET, HEAD and
+// OPTIONS requests, Write to the others, unless a route has a policy of its
+// own.
+type RateLimit struct {
+	Read   Policy        `yaml:"read" toml:"read"`
+	Write  Policy        `yaml:"write" toml:"write"`
+	Routes []RoutePolicy `yaml:"routes" toml:"routes"` // only settable in the config file
+}
+
+// Policy lets clients make Requests per Period on average, and up to Burst at
+// once. Zero requests means no limit.
+type Policy struct {
+	Requests int      `yaml:"requests" toml:"requests"`
+	Period   Duration `yaml:"period" toml:"period"`
+	Burst    int      `yaml:"burst" toml:"burst"` // Requests if 0
+}
+
+// RoutePolicy is the policy of a route, replacing Read and Write.
+type RoutePolicy struct {
+	Route   string   `yaml:"route" toml:"route"`                         // path template like /food/{id:[0-9]+}/, under any version prefix
+	Methods []string `yaml:"methods,omitempty" toml:"methods,omitempty"` // all if empty
+	Limit   Policy   `yaml:"limit" toml:"limit"`
+}
+
+// CORS configures cross-origin requests from browsers. CORS is off while
+// AllowedOrigins is empty.
+type CORS struct {
+	AllowedOrigins   []string `yaml:"allowedOrigins" toml:"allowedOrigins"` // origins like https://app.example.com, or "*" for any
+	AllowedMethods   []string `yaml:"allowedMethods" toml:"allowedMethods"`
+	AllowedHeaders   []string `yaml:"allowedHeaders" toml:"allowedHeaders"` // request headers besides the CORS-safelisted ones
+	ExposedHeaders   []string `yaml:"exposedHeaders" toml:"exposedHeaders"` // response headers scripts may read besides the safelisted ones
+	AllowCredentials bool     `yaml:"allowCredentials" toml:"allowCredentials"`
+	MaxAge           Duration `yaml:"maxAge" toml:"maxAge"` // how long browsers may cache preflight responses
+}
+
+type Log struct {
+	Level  string `yaml:"level" toml:"level"`   // debug, info, warn or error
+	Format string `yaml:"format" toml:"format"` // json or text
+}
+
+type Trace struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter"` // one of the tracing.Exporter constants
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint"`
+	SampleRatio float64 `yaml:"sampleRatio" toml:"sampleRatio"`
+}
+
+type Notify struct {
+	Interval  Duration `yaml:"interval" toml:"interval"`   // how often to scan for expiring food
+	LeadHours []int    `yaml:"leadHours" toml:"leadHours"` // default lead times of notifications
+	Log       bool     `yaml:"log" toml:"log"`
+	Webhook   string   `yaml:"webhook" toml:"webhook"` // URL to POST notifications to
+	SMTP      string   `yaml:"smtp" toml:"smtp"`       // host:port of a relay to mail notifications through
+	From      string   `yaml:"from" toml:"from"`
+}
+
+type Events struct {
+	Buffer int `yaml:"buffer" toml:"buffer"` // recent events kept for clients to resume from
+}
+
+// Duration is a time.Duration written like "30s" in files, environment
+// variables and flags.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Default returns the configuration used where nothing else is set.
+func Default() Config {
+	return Config{
+		Listen:  "localhost:8080",
+		TLS:     TLS{CertFile: "cert.pem", KeyFile: "key.pem"},
+		Auth:    Auth{Backend: AuthStatic},
+		Storage: Storage{Backend: StorageMemory},
+		Timeouts: Timeouts{
+			ReadHeader: Duration(5 * time.Second),
+			Read:       Duration(30 * time.Second),
+			Write:      Duration(60 * time.Second),
+			Idle:       Duration(2 * time.Minute),
+			Request:    Duration(10 * time.Second),
+			Shutdown:   Duration(30 * time.Second),
+		},
+		Limits: Limits{HeaderBytes: 1 << 20, BodyBytes: 1 << 20, ImportBodyBytes: 32 << 20},
+		RateLimit: RateLimit{
+			Read:  Policy{Requests: 600, Period: Duration(time.Minute), Burst: 100},
+			Write: Policy{Requests: 120, Period: Duration(time.Minute), Burst: 20},
+			Routes: []RoutePolicy{
+				// Probes and scrapes come often, from few addresses.
+				{Route: "/healthz"},
+				{Route: "/readyz"},
+				{Route: "/metrics"},
+				{Route: "/import", Methods: []string{"POST"}, Limit: Policy{Requests: 10, Period: Duration(time.Hour), Burst: 2}},
+			},
+		},
+		CORS: CORS{
+			AllowedOrigins: []string{},
+			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "X-Request-ID"},
+			ExposedHeaders: []string{
+				"WWW-Authenticate", "X-Request-ID", "Retry-After",
+				"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset",
+				"Deprecation", "Sunset", "Link",
+			},
+			MaxAge: Duration(10 * time.Minute),
+		},
+		Log:    Log{Level: "info", Format: "json"},
+		Trace:  Trace{Exporter: tracing.ExporterNone, Endpoint: "localhost:4317", SampleRatio: 1},
+		Notify: Notify{Interval: Duration(time.Minute), LeadHours: []int{72, 24}, Log: true, From: "groceries@localhost"},
+		Events: Events{Buffer: 1024},
+	}
+}
+
+// Load builds the configuration from the defaults, the config file named by
+// -config or GROCERY_CONFIG, the environment and the command line args,
+// without the program name, and validates it. printConfig is set by
+// -print-config. lookupEnv is usually os.LookupEnv.
+func Load(args []string, lookupEnv func(string) (string, bool)) (cfg Config, printConfig bool, err error) {
+	cfg = Default()
+	var file string
+	if v, ok := lookupEnv(EnvPrefix + "CONFIG"); ok {
+		file = v
+	}
+
+	fs := flag.NewFlagSet("rest-server", flag.ContinueOnError)
+	fs.StringVar(&file, "config", file, "YAML (.yaml, .yml) or TOML (.toml) config file")
+	fs.BoolVar(&printConfig, "print-config", false, "print the effective configuration as YAML and exit")
+	cfg.flags(fs)
+
+	// The flags are parsed twice: first to find the config file, then again
+	// so that they override it and the environment.
+	if err := fs.Parse(args); err != nil {
+		return cfg, false, err
+	}
+	if file != "" {
+		if err := cfg.readFile(file); err != nil {
+			return cfg, false, err
+		}
+	}
+	if err := applyEnv(reflect.ValueOf(&cfg).Elem(), strings.TrimSuffix(EnvPrefix, "_"), lookupEnv); err != nil {
+		return cfg, false, err
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, false, err
+	}
+	return cfg, printConfig, cfg.Validate()
+}
+
+// flags defines the command line flags setting cfg, with its current values
+// as defaults.
+func (cfg *Config) flags(fs *flag.FlagSet) {
+	fs.StringVar(&cfg.Listen, "listen", cfg.Listen, "host:port to serve on")
+	fs.StringVar(&cfg.TLS.CertFile, "certfile", cfg.TLS.CertFile, "certificate PEM file")
+	fs.StringVar(&cfg.TLS.KeyFile, "keyfile", cfg.TLS.KeyFile, "key PEM file")
+	fs.StringVar(&cfg.Auth.Backend, "auth-backend", cfg.Auth.Backend, "where users are authenticated: static")
+	fs.StringVar(&cfg.Storage.Backend, "storage-backend", cfg.Storage.Backend, "where food is stored: memory")
+	fs.Var(value(&cfg.Timeouts.ReadHeader), "read-header-timeout", "how long clients may take to send the request headers")
+	fs.Var(value(&cfg.Timeouts.Read), "read-timeout", "how long clients may take to send the whole request")
+	fs.Var(value(&cfg.Timeouts.Write), "write-timeout", "how long the server may take to write the response")
+	fs.Var(value(&cfg.Timeouts.Idle), "idle-timeout", "how long keep-alive connections wait for the next request")
+	fs.Var(value(&cfg.Timeouts.Request), "request-timeout", "how long a request may take before handlers and the store give up")
+	fs.Var(value(&cfg.Timeouts.Shutdown), "shutdown-timeout", "how long to wait for requests in flight when shutting down")
+	fs.IntVar(&cfg.Limits.HeaderBytes, "max-header-bytes", cfg.Limits.HeaderBytes, "largest request line and headers accepted")
+	fs.Int64Var(&cfg.Limits.BodyBytes, "max-body-bytes", cfg.Limits.BodyBytes, "largest request body accepted, except for imports")
+	fs.Int64Var(&cfg.Limits.ImportBodyBytes, "max-import-bytes", cfg.Limits.ImportBodyBytes, "largest archive accepted by /import")
+	fs.Var(value(&cfg.CORS.AllowedOrigins), "cors-origins", "comma separated origins allowed to make cross-origin requests, or * for any")
+	fs.StringVar(&cfg.Log.Level, "log-level", cfg.Log.Level, "lowest level logged: debug, info, warn or error")
+	fs.StringVar(&cfg.Log.Format, "log-format", cfg.Log.Format, "format of log records: json or text")
+	fs.StringVar(&cfg.Trace.Exporter, "trace-exporter", cfg.Trace.Exporter, "where to send trace spans: none, stdout or otlp")
+	fs.StringVar(&cfg.Trace.Endpoint, "trace-endpoint", cfg.Trace.Endpoint, "host:port of the OTLP collector receiving spans over gRPC")
+	fs.Float64Var(&cfg.Trace.SampleRatio, "trace-sample", cfg.Trace.SampleRatio, "fraction of new traces recorded, between 0 and 1")
+	fs.Var(value(&cfg.Notify.Interval), "notify-interval", "how often to scan for expiring and expired food")
+	fs.Var(value(&cfg.Notify.LeadHours), "notify-lead", "comma separated default lead times in hours for expiration notifications")
+	fs.BoolVar(&cfg.Notify.Log, "notify-log", cfg.Notify.Log, "log expiration notifications")
+	fs.StringVar(&cfg.Notify.Webhook, "notify-webhook", cfg.Notify.Webhook, "URL to POST expiration notifications to")
+	fs.StringVar(&cfg.Notify.SMTP, "notify-smtp", cfg.Notify.SMTP, "host:port of an SMTP relay to mail expiration notifications through")
+	fs.StringVar(&cfg.Notify.From, "notify-from", cfg.Notify.From, "sender address of notification mail")
+	fs.IntVar(&cfg.Events.Buffer, "event-buffer", cfg.Events.Buffer, "number of recent events kept for /events clients to resume from")
+}
+
+// readFile overrides cfg with the settings in a config file. Unknown keys are
+// errors, so that typos don't go unnoticed.
+func (cfg *Config) readFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	switch ext := filepath.Ext(file); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(strings.NewReader(string(data)))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && err != io.EOF {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("%s: unknown key %s", file, undecoded[0])
+		}
+	default:
+		return fmt.Errorf("%s: unknown config file type %q, expect .yaml, .yml or .toml", file, ext)
+	}
+	return nil
+}
+
+// WriteYAML writes cfg to w in the format of a config file.
+func (cfg Config) WriteYAML(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Validate checks cfg, reporting all the problems found.
+func (cfg Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	_, _, err := net.SplitHostPort(cfg.Listen)
+	check(err == nil, "listen: %q isn't a host:port", cfg.Listen)
+	check(cfg.TLS.CertFile != "" && cfg.TLS.KeyFile != "", "tls: certFile and keyFile are required")
+	check(cfg.Auth.Backend == AuthStatic, "auth.backend: unknown backend %q, expect %s", cfg.Auth.Backend, AuthStatic)
+	check(cfg.Storage.Backend == StorageMemory, "storage.backend: unknown backend %q, expect %s", cfg.Storage.Backend, StorageMemory)
+	timeouts := []struct {
+		name string
+		d    Duration
+	}{
+		{"readHeader", cfg.Timeouts.ReadHeader},
+		{"read", cfg.Timeouts.Read},
+		{"write", cfg.Timeouts.Write},
+		{"idle", cfg.Timeouts.Idle},
+		{"request", cfg.Timeouts.Request},
+		{"shutdown", cfg.Timeouts.Shutdown},
+	}
+	for _, t := range timeouts {
+		check(t.d > 0, "timeouts.%s: must be positive", t.name)
+	}
+	check(cfg.Timeouts.Request <= cfg.Timeouts.Write, "timeouts.request: can't be longer than timeouts.write, which cuts responses off")
+	check(cfg.Limits.HeaderBytes > 0, "limits.headerBytes: must be positive")
+	check(cfg.Limits.BodyBytes > 0, "limits.bodyBytes: must be positive")
+	check(cfg.Limits.ImportBodyBytes > 0, "limits.importBodyBytes: must be positive")
+	errs = append(errs, cfg.RateLimit.Read.validate("rateLimit.read")...)
+	errs = append(errs, cfg.RateLimit.Write.validate("rateLimit.write")...)
+	for i, r := range cfg.RateLimit.Routes {
+		name := fmt.Sprintf("rateLimit.routes[%d]", i)
+		check(strings.HasPrefix(r.Route, "/"), "%s.route: %q isn't a path template", name, r.Route)
+		errs = append(errs, r.Limit.validate(name+".limit")...)
+	}
+
+	for _, origin := range cfg.CORS.AllowedOrigins {
+		check(validOrigin(origin), "cors.allowedOrigins: %q isn't * or an origin like https://example.com", origin)
+	}
+	check(!cfg.CORS.AllowCredentials || !contains(cfg.CORS.AllowedOrigins, "*"), "cors.allowCredentials: can't be used with the * origin")
+	check(cfg.CORS.MaxAge >= 0, "cors.maxAge: can't be negative")
+
+	check(contains([]string{"debug", "info", "warn", "error"}, cfg.Log.Level), "log.level: unknown level %q, expect debug, info, warn or error", cfg.Log.Level)
+	check(contains([]string{"json", "text"}, cfg.Log.Format), "log.format: unknown format %q, expect json or text", cfg.Log.Format)
+	check(contains([]string{tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP}, cfg.Trace.Exporter),
+		"trace.exporter: unknown exporter %q, expect %s, %s or %s", cfg.Trace.Exporter, tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP)
+	check(cfg.Trace.SampleRatio >= 0 && cfg.Trace.SampleRatio <= 1, "trace.sampleRatio: must be between 0 and 1")
+
+	check(cfg.Notify.Interval > 0, "notify.interval: must be positive")
+	for _, h := range cfg.Notify.LeadHours {
+		check(h > 0, "notify.leadHours: expect positive numbers of hours, got %d", h)
+	}
+	check(cfg.Events.Buffer > 0, "events.buffer: must be positive")
+	return errors.Join(errs...)
+}
+
+func (p Policy) validate(name string) []error {
+	var errs []error
+	switch {
+	case p.Requests < 0 || p.Burst < 0:
+		errs = append(errs, fmt.Errorf("%s: requests and burst can't be negative", name))
+	case p.Requests > 0 && p.Period <= 0:
+		errs = append(errs, fmt.Errorf("%s.period: must be positive", name))
+	}
+	return errs
+}
+
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.Path == "" && u.RawQuery == ""
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// applyEnv sets the fields of the struct v from the environment variables
+// named prefix_KEY, where KEY is the field's file key in upper snake case.
+func applyEnv(v reflect.Value, prefix string, lookupEnv func(string) (string, bool)) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := prefix + "_" + envName(strings.Split(field.Tag.Get("yaml"), ",")[0])
+		fv := v.Field(i)
+		if _, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); !ok && fv.Kind() == reflect.Struct {
+			if err := applyEnv(fv, name, lookupEnv); err != nil {
+				return err
+			}
+			continue
+		}
+		if s, ok := lookupEnv(name); ok {
+			if err := setValue(fv, s); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// envName turns a key like certFile into CERT_FILE.
+func envName(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// setValue parses s into v, which is a text unmarshaler, a string, bool,
+// int, int64 or float, or a list of strings or ints.
+func setValue(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		list := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, part := range strings.Split(s, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setValue(elem, part); err != nil {
+				return err
+			}
+			list = reflect.Append(list, elem)
+		}
+		v.Set(list)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// flagValue is a flag.Value setting a field with setValue, for the types the
+// flag package lacks.
+type flagValue struct {
+	v reflect.Value
+}
+
+func value(ptr interface{}) flagValue {
+	return flagValue{reflect.ValueOf(ptr).Elem()}
+}
+
+func (f flagValue) Set(s string) error {
+	return setValue(f.v, s)
+}
+
+func (f flagValue) String() string {
+	if !f.v.IsValid() {
+		return ""
+	}
+	if m, ok := f.v.Interface().(encoding.TextMarshaler); ok {
+		b, _ := m.MarshalText()
+		return string(b)
+	}
+	if f.v.Kind() == reflect.Slice {
+		parts := make([]string, f.v.Len())
+		for i := range parts {
+			parts[i] = fmt.Sprint(f.v.Index(i).Interface())
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(f.v.Interface())
+}
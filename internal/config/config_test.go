@@ -0,0 +1,126 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLayers(t *testing.T) {
+	file := writeFile(t, "server.yaml", `
+listen: 0.0.0.0:8443
+log:
+  level: debug
+notify:
+  leadHours: [48]
+  interval: 5m
+`)
+	cfg, printConfig, err := Load(
+		[]string{"-config", file, "-log-level", "warn"},
+		env(map[string]string{"GROCERY_LISTEN": ":9000", "GROCERY_CORS_ALLOWED_ORIGINS": "https://app.example.com, http://localhost:3000"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if printConfig {
+		t.Error("printConfig set without -print-config")
+	}
+
+	want := Default()
+	want.Listen = ":9000"   // the environment overrides the file
+	want.Log.Level = "warn" // flags override the file
+	want.Notify.LeadHours = []int{48}
+	want.Notify.Interval = Duration(5 * time.Minute)
+	want.CORS.AllowedOrigins = []string{"https://app.example.com", "http://localhost:3000"}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %+v\nwant %+v", cfg, want)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	file := writeFile(t, "server.toml", `
+[timeouts]
+shutdown = "5s"
+
+[trace]
+exporter = "otlp"
+`)
+	cfg, _, err := Load(nil, env(map[string]string{"GROCERY_CONFIG": file}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Timeouts.Shutdown != Duration(5*time.Second) || cfg.Trace.Exporter != "otlp" {
+		t.Errorf("got %+v", cfg)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want string
+	}{
+		{"unknown key", []string{"-config", writeFile(t, "c.yaml", "lisen: :80\n")}, nil, "lisen"},
+		{"unknown TOML key", []string{"-config", writeFile(t, "c.toml", "lisen = \":80\"\n")}, nil, "lisen"},
+		{"unknown file type", []string{"-config", writeFile(t, "c.json", "{}")}, nil, "config file type"},
+		{"bad env value", nil, map[string]string{"GROCERY_EVENTS_BUFFER": "many"}, "GROCERY_EVENTS_BUFFER"},
+		{"invalid listen", []string{"-listen", "8080"}, nil, "listen"},
+		{"unknown storage", []string{"-storage-backend", "postgres"}, nil, "storage.backend"},
+		{"credentials with any origin", []string{"-cors-origins", "*"}, map[string]string{"GROCERY_CORS_ALLOW_CREDENTIALS": "true"}, "cors.allowCredentials"},
+		{"origin with path", []string{"-cors-origins", "https://example.com/app"}, nil, "cors.allowedOrigins"},
+		{"negative lead", []string{"-notify-lead", "24,-1"}, nil, "notify.leadHours"},
+		{"zero timeout", nil, map[string]string{"GROCERY_TIMEOUTS_IDLE": "0s"}, "timeouts.idle"},
+		{"request outlasting write", []string{"-request-timeout", "2m"}, nil, "timeouts.request"},
+		{"negative rate", nil, map[string]string{"GROCERY_RATE_LIMIT_WRITE_REQUESTS": "-1"}, "rateLimit.write"},
+		{"route rate without period", []string{"-config", writeFile(t, "c.yaml", "rateLimit:\n  routes:\n    - route: /food/\n      limit: {requests: 5}\n")}, nil, "rateLimit.routes[0].limit.period"},
+		{"bad body limit", nil, map[string]string{"GROCERY_LIMITS_IMPORT_BODY_BYTES": "1e9"}, "GROCERY_LIMITS_IMPORT_BODY_BYTES"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Load(tt.args, env(tt.env))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one about %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Default().WriteYAML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "shutdown: 30s") {
+		t.Errorf("got %s, want durations like 30s", buf.String())
+	}
+
+	// The output is a valid config file.
+	cfg, _, err := Load([]string{"-config", writeFile(t, "printed.yaml", buf.String())}, env(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("got %+v, want the defaults back", cfg)
+	}
+}
@@ -0,0 +1,140 @@
+// Cross-origin resource sharing (CORS), for browser frontends served from
+// other origins.
+//
+// The handler wraps the router: browsers send preflight requests without
+// credentials, with the OPTIONS method no route serves, so they are answered
+// before routing and authentication. Other requests from allowed origins go
+// on to the router with the CORS headers set, so that scripts can read errors
+// too, like the 401 of BasicAuth and its WWW-Authenticate challenge.
+
+package cors
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diorchen/rest-server/internal/config"
+	"github.com/diorchen/rest-server/internal/middleware"
+)
+
+type handler struct {
+	cfg       config.CORS
+	anyOrigin bool
+	methods   string // allowed methods, comma separated
+	headers   string // allowed request headers, comma separated
+	exposed   string // exposed response headers, comma separated
+	next      http.Handler
+}
+
+// New wraps next to handle the cross-origin requests allowed by cfg. It
+// returns next as is if cfg allows no origins.
+func New(cfg config.CORS, next http.Handler) http.Handler {
+	if len(cfg.AllowedOrigins) == 0 {
+		return next
+	}
+	return &handler{
+		cfg:       cfg,
+		anyOrigin: slices.Contains(cfg.AllowedOrigins, "*"),
+		methods:   strings.Join(cfg.AllowedMethods, ", "),
+		headers:   strings.Join(cfg.AllowedHeaders, ", "),
+		exposed:   strings.Join(cfg.ExposedHeaders, ", "),
+		next:      next,
+	}
+}
+
+// CheckOrigin returns the origin check of WebSocket handshakes, which browsers
+// send without a preflight. Like the default of gorilla/websocket, it accepts
+// requests without an Origin header, which don't come from browsers, and from
+// the server's own origin; other origins must be allowed by cfg.
+func CheckOrigin(cfg config.CORS) func(*http.Request) bool {
+	h := &handler{cfg: cfg, anyOrigin: slices.Contains(cfg.AllowedOrigins, "*")}
+	return func(req *http.Request) bool {
+		origin := req.Header.Get("Origin")
+		if origin == "" || h.allowedOrigin(origin) {
+			return true
+		}
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, req.Host)
+	}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	origin := req.Header.Get("Origin")
+	if req.Method == http.MethodOptions && origin != "" && req.Header.Get("Access-Control-Request-Method") != "" {
+		h.preflight(w, req, origin)
+		return
+	}
+
+	w.Header().Add("Vary", "Origin")
+	if origin != "" && h.allowedOrigin(origin) {
+		h.allowOrigin(w, origin)
+		if h.exposed != "" {
+			w.Header().Set("Access-Control-Expose-Headers", h.exposed)
+		}
+	}
+	h.next.ServeHTTP(w, req)
+}
+
+// preflight answers a preflight request. Requests the configuration doesn't
+// allow get no CORS headers, which makes the browser refuse to send the
+// actual request.
+func (h *handler) preflight(w http.ResponseWriter, req *http.Request, origin string) {
+	w.Header().Add("Vary", "Origin")
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	method := req.Header.Get("Access-Control-Request-Method")
+	switch {
+	case !h.allowedOrigin(origin):
+		middleware.Logger(req.Context()).Debug("preflight from disallowed origin", "origin", origin)
+	case !slices.Contains(h.cfg.AllowedMethods, method):
+		middleware.Logger(req.Context()).Debug("preflight for disallowed method", "origin", origin, "method", method)
+	case !h.allowedHeaders(req.Header.Get("Access-Control-Request-Headers")):
+		middleware.Logger(req.Context()).Debug("preflight for disallowed headers", "origin", origin,
+			"headers", req.Header.Get("Access-Control-Request-Headers"))
+	default:
+		h.allowOrigin(w, origin)
+		w.Header().Set("Access-Control-Allow-Methods", h.methods)
+		if h.headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", h.headers)
+		}
+		if h.cfg.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(time.Duration(h.cfg.MaxAge).Seconds())))
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) allowOrigin(w http.ResponseWriter, origin string) {
+	if h.anyOrigin {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if h.cfg.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (h *handler) allowedOrigin(origin string) bool {
+	return h.anyOrigin || slices.Contains(h.cfg.AllowedOrigins, origin)
+}
+
+// allowedHeaders reports whether all the headers in the comma separated list
+// are allowed. Header names are case-insensitive.
+func (h *handler) allowedHeaders(list string) bool {
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.ContainsFunc(h.cfg.AllowedHeaders, func(allowed string) bool { return strings.EqualFold(allowed, name) }) {
+			return false
+		}
+	}
+	return true
+}
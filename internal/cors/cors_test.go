@@ -0,0 +1,154 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/diorchen/rest-server/internal/config"
+	"github.com/diorchen/rest-server/internal/middleware"
+)
+
+const app = "https://app.example.com"
+
+func newHandler(cfg config.CORS) http.Handler {
+	router := mux.NewRouter()
+	router.Handle("/food/", middleware.BasicAuth(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))).Methods("POST")
+	return New(cfg, router)
+}
+
+func corsConfig() config.CORS {
+	cfg := config.Default().CORS
+	cfg.AllowedOrigins = []string{app}
+	cfg.AllowCredentials = true
+	cfg.MaxAge = config.Duration(time.Hour)
+	return cfg
+}
+
+func TestPreflight(t *testing.T) {
+	h := newHandler(corsConfig())
+
+	tests := []struct {
+		name    string
+		origin  string
+		method  string
+		headers string
+		allowed bool
+	}{
+		{"allowed", app, "POST", "authorization, content-type", true},
+		{"other origin", "https://evil.example.com", "POST", "", false},
+		{"disallowed method", app, "PATCH", "", false},
+		{"disallowed header", app, "POST", "Authorization, X-Debug", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("OPTIONS", "/food/", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			req.Header.Set("Access-Control-Request-Headers", tt.headers)
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			// Preflights carry no credentials, so they must not be challenged.
+			if rr.Code != http.StatusNoContent || rr.Header().Get("WWW-Authenticate") != "" {
+				t.Errorf("got status %d with headers %v, want 204 without a challenge", rr.Code, rr.Header())
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Origin") == tt.origin; got != tt.allowed {
+				t.Errorf("got Access-Control-Allow-Origin %q, want allowed %v", rr.Header().Get("Access-Control-Allow-Origin"), tt.allowed)
+			}
+			if tt.allowed {
+				want := map[string]string{
+					"Access-Control-Allow-Methods":     "GET, POST, PUT, DELETE",
+					"Access-Control-Allow-Headers":     "Authorization, Content-Type, X-Request-ID",
+					"Access-Control-Allow-Credentials": "true",
+					"Access-Control-Max-Age":           "3600",
+				}
+				for name, value := range want {
+					if got := rr.Header().Get(name); got != value {
+						t.Errorf("got %s %q, want %q", name, got, value)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestActualRequest(t *testing.T) {
+	h := newHandler(corsConfig())
+
+	// The browser must let the script read the 401 and its challenge.
+	req := httptest.NewRequest("POST", "/food/", nil)
+	req.Header.Set("Origin", app)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized || rr.Header().Get("Access-Control-Allow-Origin") != app {
+		t.Errorf("got status %d with headers %v, want a 401 readable by %s", rr.Code, rr.Header(), app)
+	}
+	if exposed := rr.Header().Get("Access-Control-Expose-Headers"); !strings.Contains(exposed, "WWW-Authenticate") {
+		t.Errorf("got Access-Control-Expose-Headers %q, want the challenge exposed", exposed)
+	}
+	if vary := rr.Header().Values("Vary"); len(vary) == 0 || vary[0] != "Origin" {
+		t.Errorf("got Vary %q, want Origin", vary)
+	}
+
+	req = httptest.NewRequest("POST", "/food/", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("other origin: got headers %v, want no CORS headers", rr.Header())
+	}
+}
+
+func TestAnyOrigin(t *testing.T) {
+	cfg := config.Default().CORS
+	cfg.AllowedOrigins = []string{"*"}
+	req := httptest.NewRequest("GET", "/food/", nil)
+	req.Header.Set("Origin", app)
+	rr := httptest.NewRecorder()
+	newHandler(cfg).ServeHTTP(rr, req)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "*" || rr.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("got headers %v, want any origin without credentials", rr.Header())
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	req := httptest.NewRequest("OPTIONS", "/food/", nil)
+	req.Header.Set("Origin", app)
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rr := httptest.NewRecorder()
+	newHandler(config.Default().CORS).ServeHTTP(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed || rr.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("got status %d with headers %v, want the router's 405", rr.Code, rr.Header())
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		cfg     []string
+		origin  string
+		allowed bool
+	}{
+		{nil, "", true},
+		{nil, "https://localhost:8080", true},
+		{nil, app, false},
+		{[]string{app}, app, true},
+		{[]string{app}, "https://evil.example.com", false},
+		{[]string{"*"}, "https://evil.example.com", true},
+	}
+	for _, tt := range tests {
+		cfg := config.Default().CORS
+		cfg.AllowedOrigins = tt.cfg
+		req := httptest.NewRequest("GET", "https://localhost:8080/ws", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		if got := CheckOrigin(cfg)(req); got != tt.allowed {
+			t.Errorf("origins %v, origin %q: got %v, want %v", tt.cfg, tt.origin, got, tt.allowed)
+		}
+	}
+}
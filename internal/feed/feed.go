@@ -0,0 +1,139 @@
+// Change feed of store events for streaming clients.
+//
+// A Hub receives every event published by the store, remembers the most recent
+// ones in a bounded ring buffer and fans them out to subscribers. Subscribers
+// that reconnect can resume from the last event they saw, as long as it is
+// still in the buffer.
+
+package feed
+
+import (
+	"sync"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+)
+
+// Hub fans out store events to subscribers; Hub methods are safe to call
+// concurrently.
+type Hub struct {
+	sync.Mutex
+
+	ring  []groceryItemStore.Event // the latest events, oldest at ring[start]
+	start int
+	count int
+
+	subs   map[*Subscription]bool
+	closed bool
+}
+
+// Subscription receives the events published after it was created on C. If the
+// subscriber doesn't keep up and C fills up, the subscription is dropped and C
+// closed; the subscriber should then resubscribe from the last event it got.
+type Subscription struct {
+	C <-chan groceryItemStore.Event
+
+	c   chan groceryItemStore.Event
+	hub *Hub
+}
+
+// NewHub creates a Hub remembering the latest size events.
+func NewHub(size int) *Hub {
+	return &Hub{
+		ring: make([]groceryItemStore.Event, size),
+		subs: make(map[*Subscription]bool),
+	}
+}
+
+// Publish adds ev to the buffer and sends it to all subscribers. It never
+// blocks, so it can be passed to GroceryItemStore.Subscribe.
+func (h *Hub) Publish(ev groceryItemStore.Event) {
+	h.Lock()
+	defer h.Unlock()
+
+	if len(h.ring) > 0 {
+		if h.count < len(h.ring) {
+			h.ring[(h.start+h.count)%len(h.ring)] = ev
+			h.count++
+		} else {
+			h.ring[h.start] = ev
+			h.start = (h.start + 1) % len(h.ring)
+		}
+	}
+
+	for sub := range h.subs {
+		select {
+		case sub.c <- ev:
+		default:
+			// Too slow; drop it rather than hold up the store.
+			h.drop(sub)
+		}
+	}
+}
+
+// Subscribe starts a subscription whose channel buffers up to buffer events.
+//
+// If lastId is positive, the buffered events after lastId are returned for
+// replay, and complete reports whether they cover everything since lastId; if
+// lastId has already fallen out of the buffer it is false and the subscriber
+// has missed events. Replayed events and events on the channel don't overlap.
+func (h *Hub) Subscribe(lastId int, buffer int) (sub *Subscription, replay []groceryItemStore.Event, complete bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	c := make(chan groceryItemStore.Event, buffer)
+	sub = &Subscription{C: c, c: c, hub: h}
+	if h.closed {
+		close(c)
+		return sub, nil, false
+	}
+	h.subs[sub] = true
+
+	complete = true
+	if lastId > 0 {
+		// A lastId newer than anything buffered comes from before a restart.
+		complete = h.count > 0 && h.ring[h.start].Id <= lastId+1 &&
+			lastId <= h.ring[(h.start+h.count-1)%len(h.ring)].Id
+		for i := 0; i < h.count; i++ {
+			ev := h.ring[(h.start+i)%len(h.ring)]
+			if ev.Id > lastId {
+				replay = append(replay, ev)
+			}
+		}
+	}
+	return sub, replay, complete
+}
+
+// Close stops the subscription and closes its channel.
+func (sub *Subscription) Close() {
+	sub.hub.Lock()
+	defer sub.hub.Unlock()
+
+	if sub.hub.subs[sub] {
+		sub.hub.drop(sub)
+	}
+}
+
+// Close closes all subscriptions; later ones are closed right away.
+func (h *Hub) Close() {
+	h.Lock()
+	defer h.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		h.drop(sub)
+	}
+}
+
+// Closed reports whether the hub was closed, to tell subscriptions closed for
+// shutting down from those dropped for falling behind.
+func (h *Hub) Closed() bool {
+	h.Lock()
+	defer h.Unlock()
+	return h.closed
+}
+
+// drop removes sub and closes its channel. The caller must hold the lock.
+func (h *Hub) drop(sub *Subscription) {
+	delete(h.subs, sub)
+	close(sub.c)
+}
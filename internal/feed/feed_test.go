@@ -0,0 +1,73 @@
+package feed
+
+import (
+	"testing"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+)
+
+func publish(h *Hub, from, to int) {
+	for id := from; id <= to; id++ {
+		h.Publish(groceryItemStore.Event{Id: id, Type: groceryItemStore.FoodUpdated})
+	}
+}
+
+func TestSubscribeAndResume(t *testing.T) {
+	h := NewHub(4)
+	publish(h, 1, 6) // the buffer now holds 3..6
+
+	var tests = []struct {
+		lastId       int
+		wantReplay   int
+		wantComplete bool
+	}{
+		{0, 0, true},
+		{1, 4, false},
+		{2, 4, true},
+		{5, 1, true},
+		{6, 0, true},
+		{9, 0, false},
+	}
+	for _, tt := range tests {
+		sub, replay, complete := h.Subscribe(tt.lastId, 1)
+		if len(replay) != tt.wantReplay || complete != tt.wantComplete {
+			t.Errorf("Subscribe(%d) replayed %d, complete=%v; want %d, %v",
+				tt.lastId, len(replay), complete, tt.wantReplay, tt.wantComplete)
+		}
+		if len(replay) > 0 && replay[len(replay)-1].Id != 6 {
+			t.Errorf("Subscribe(%d) replay ends at %d, want 6", tt.lastId, replay[len(replay)-1].Id)
+		}
+		sub.Close()
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	h := NewHub(8)
+	slow, _, _ := h.Subscribe(0, 1)
+	fast, _, _ := h.Subscribe(0, 8)
+	publish(h, 1, 3)
+
+	if ev := <-slow.C; ev.Id != 1 {
+		t.Errorf("got event %d, want 1", ev.Id)
+	}
+	if _, ok := <-slow.C; ok {
+		t.Error("slow subscriber still open, want it dropped")
+	}
+	for want := 1; want <= 3; want++ {
+		if ev := <-fast.C; ev.Id != want {
+			t.Errorf("got event %d, want %d", ev.Id, want)
+		}
+	}
+
+	if h.Closed() {
+		t.Error("hub closed by dropping a subscriber")
+	}
+	h.Close()
+	if _, ok := <-fast.C; ok {
+		t.Error("subscriber open after hub closed")
+	}
+	if !h.Closed() {
+		t.Error("hub not closed")
+	}
+	fast.Close() // closing twice is fine
+}
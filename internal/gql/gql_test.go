@@ -0,0 +1,253 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"github.com/diorchen/rest-server/internal/feed"
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/middleware"
+)
+
+func newTestSchema(t *testing.T) (*graphql.Schema, *groceryItemStore.GroceryItemStore) {
+	gis := groceryItemStore.New()
+	hub := feed.NewHub(16)
+	gis.Subscribe(hub.Publish)
+	schema, err := NewSchema(gis, hub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema, gis
+}
+
+func exec(t *testing.T, ctx context.Context, schema *graphql.Schema, query string, result interface{}) []string {
+	resp := schema.Exec(ctx, query, "", nil)
+	var errs []string
+	for _, err := range resp.Errors {
+		errs = append(errs, err.Message)
+	}
+	if len(errs) == 0 && result != nil {
+		if err := json.Unmarshal(resp.Data, result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return errs
+}
+
+func TestQueries(t *testing.T) {
+	schema, gis := newTestSchema(t)
+	fridge, _ := gis.CreateLocation(context.Background(), "Fridge", groceryItemStore.KindFridge)
+	gis.AddFood(context.Background(), groceryItemStore.FoodItem{
+		Name:        "Yogurt",
+		Ingredients: []string{"Milk"},
+		Expiration:  time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC),
+		Nutrition:   groceryItemStore.Nutrition{Calories: 59},
+		Location:    fridge,
+	})
+	gis.CreateFood(context.Background(), "Rice", "", []string{"Rice"}, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), groceryItemStore.Nutrition{})
+
+	var result struct {
+		ByIng []struct {
+			Name      string
+			Nutrition struct{ Calories int }
+			Location  struct{ Name string }
+		}
+		ByExp []struct{ Name string }
+		All   []struct{ Id string }
+	}
+	errs := exec(t, context.Background(), schema, `{
+		byIng: foodsByIngredient(ingredient: "Milk") { name nutrition { calories } location { name } }
+		byExp: foodsByExpiration(year: 2024, month: 7, day: 1) { name }
+		all: foods { id }
+	}`, &result)
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	if len(result.ByIng) != 1 || result.ByIng[0].Nutrition.Calories != 59 || result.ByIng[0].Location.Name != "Fridge" {
+		t.Errorf("got foodsByIngredient %+v", result.ByIng)
+	}
+	if len(result.ByExp) != 1 || result.ByExp[0].Name != "Rice" {
+		t.Errorf("got foodsByExpiration %+v", result.ByExp)
+	}
+	if len(result.All) != 2 {
+		t.Errorf("got %d foods, want 2", len(result.All))
+	}
+}
+
+func TestMutationsNeedAuth(t *testing.T) {
+	schema, gis := newTestSchema(t)
+	mutation := `mutation { createFood(input: {name: "Kiwi", expiration: "2023-07-01T00:00:00Z"}) { id name } }`
+
+	if errs := exec(t, context.Background(), schema, mutation, nil); len(errs) != 1 {
+		t.Fatalf("got errors %v, want unauthorized", errs)
+	}
+
+	ctx := context.WithValue(context.Background(), middleware.UserContextKey, "joe")
+	var created struct {
+		CreateFood struct{ Id, Name string }
+	}
+	if errs := exec(t, ctx, schema, mutation, &created); errs != nil {
+		t.Fatal(errs)
+	}
+	if created.CreateFood.Name != "Kiwi" {
+		t.Errorf("got %+v", created)
+	}
+
+	if errs := exec(t, ctx, schema, `mutation { deleteFood(id: "`+created.CreateFood.Id+`") { name } }`, nil); errs != nil {
+		t.Fatal(errs)
+	}
+	if food, _ := gis.GetAllFood(context.Background()); len(food) != 0 {
+		t.Errorf("got %v after delete, want nothing", food)
+	}
+}
+
+func TestCreateFoodValidates(t *testing.T) {
+	schema, gis := newTestSchema(t)
+	ctx := context.WithValue(context.Background(), middleware.UserContextKey, "joe")
+
+	errs := exec(t, ctx, schema, `mutation { createFood(input: {name: " ", expiration: "2023-07-01T00:00:00Z", nutrition: {calories: -1}}) { id } }`, nil)
+	if len(errs) != 1 || !strings.Contains(errs[0], "name is required") || !strings.Contains(errs[0], "nutrition.calories must be at least 0") {
+		t.Errorf("got errors %v, want the invalid name and calories", errs)
+	}
+	if food, _ := gis.GetAllFood(context.Background()); len(food) != 0 {
+		t.Errorf("got %v, want the invalid food not stored", food)
+	}
+}
+
+func TestSubscription(t *testing.T) {
+	schema, gis := newTestSchema(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, err := schema.Subscribe(ctx, `subscription { foodChanged(filter: {contains: "dairy"}) { op food { name } } }`, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Give the subscription time to start before changing the store.
+	time.Sleep(50 * time.Millisecond)
+	gis.CreateFood(context.Background(), "Bread", "", nil, time.Time{}, groceryItemStore.Nutrition{})
+	gis.CreateFood(context.Background(), "Cheese", "", []string{"Dairy"}, time.Time{}, groceryItemStore.Nutrition{})
+
+	select {
+	case resp := <-c:
+		r := resp.(*graphql.Response)
+		var got struct {
+			FoodChanged struct {
+				Op   string
+				Food struct{ Name string }
+			}
+		}
+		if err := json.Unmarshal(r.Data, &got); err != nil {
+			t.Fatal(err, r.Errors)
+		}
+		if got.FoodChanged.Op != "added" || got.FoodChanged.Food.Name != "Cheese" {
+			t.Errorf("got %+v, want cheese added", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no subscription response")
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	schema, _ := newTestSchema(t)
+
+	if errs := exec(t, context.Background(), schema, `{ foods { location { food { location { name } } } } }`, nil); errs != nil {
+		t.Errorf("query within the limit: got errors %v", errs)
+	}
+	errs := exec(t, context.Background(), schema, `{ foods { location { food { location { food { name } } } } } }`, nil)
+	if len(errs) != 1 || !strings.Contains(errs[0], "depth") {
+		t.Errorf("deep query: got errors %v, want the depth exceeded", errs)
+	}
+}
+
+func TestOperationType(t *testing.T) {
+	tests := []struct {
+		query, operationName, want string
+	}{
+		{`{ foods { name } }`, "", "query"},
+		{`query Foods($id: ID!) { food(id: $id) { name } }`, "", "query"},
+		{`mutation { deleteFood(id: "1") { name } }`, "", "mutation"},
+		{"# mutation\n{ foods { name } }", "", "query"},
+		{`subscription @live { foodChanged { op } }`, "", "subscription"},
+		{`query A { foods { name } } mutation B { deleteFood(id: "1") { name } }`, "B", "mutation"},
+		{`query A { foods { name } } mutation B { deleteFood(id: "1") { name } }`, "", ""},
+		{`fragment F on Food { name } mutation { createFood(input: {name: "{ }"}) { ...F } }`, "", "mutation"},
+		{`foo bar {`, "", ""},
+	}
+	for _, tt := range tests {
+		if got := operationType(tt.query, tt.operationName); got != tt.want {
+			t.Errorf("%q, operation %q: got %q, want %q", tt.query, tt.operationName, got, tt.want)
+		}
+	}
+}
+
+func TestGetOnlyQueries(t *testing.T) {
+	schema, gis := newTestSchema(t)
+	h := &Handler{Schema: schema}
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(query), nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, "joe"))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := get(`{ foods { name } }`); rr.Code != http.StatusOK {
+		t.Errorf("query: got %d", rr.Code)
+	}
+	rr := get(`mutation { createFood(input: {name: "Kiwi", expiration: "2023-07-01T00:00:00Z"}) { id } }`)
+	if rr.Code != http.StatusMethodNotAllowed || rr.Header().Get("Allow") != "POST" {
+		t.Errorf("mutation: got %d with headers %v, want 405", rr.Code, rr.Header())
+	}
+	if food, _ := gis.GetAllFood(context.Background()); len(food) != 0 {
+		t.Errorf("got %v after a mutation over GET, want nothing", food)
+	}
+}
+
+func TestSubscriptionHandlerOnlySubscribes(t *testing.T) {
+	schema, gis := newTestSchema(t)
+	h := &Handler{Schema: schema, Subscriptions: true}
+	body := `{"query": "mutation { createFood(input: {name: \"Kiwi\", expiration: \"2023-07-01T00:00:00Z\"}) { id } }"}`
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, "joe"))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotAcceptable {
+		t.Errorf("mutation: got %d, want 406", rr.Code)
+	}
+	if food, _ := gis.GetAllFood(context.Background()); len(food) != 0 {
+		t.Errorf("got %v after a streamed mutation, want nothing", food)
+	}
+}
+
+func TestFoodErrors(t *testing.T) {
+	schema, gis := newTestSchema(t)
+
+	var result struct{ Food *struct{ Name string } }
+	if errs := exec(t, context.Background(), schema, `{ food(id: "9") { name } }`, &result); len(errs) != 0 || result.Food != nil {
+		t.Errorf("missing food: got %+v with errors %v, want null", result.Food, errs)
+	}
+
+	// A busy store isn't a missing food. Calling the resolver tells its error
+	// from that of the expired context.
+	gis.Lock()
+	defer gis.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	r := &resolver{store: gis}
+	if food, err := r.Food(ctx, struct{ Id graphql.ID }{"9"}); err == nil {
+		t.Errorf("timed out request: got %v, want an error", food)
+	}
+}
@@ -0,0 +1,213 @@
+// HTTP transport for the GraphQL schema.
+
+package gql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"github.com/diorchen/rest-server/internal/problem"
+)
+
+// Handler serves GraphQL requests over HTTP. Operations are sent as a JSON
+// body with POST; queries may also be sent as query parameters with GET, but
+// not mutations or subscriptions, since browsers send GET requests across
+// sites with the user's credentials. Requests accepting text/event-stream are
+// answered as Server-Sent Events, one "next" event per result followed by
+// "complete"; this is how subscriptions are consumed.
+//
+// Subscriptions last as long as clients want, so they're served by a Handler
+// of their own, with Subscriptions set, which the router exempts from request
+// timeouts. It refuses other operations.
+type Handler struct {
+	Schema        *graphql.Schema
+	Subscriptions bool
+}
+
+type params struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var p params
+	switch req.Method {
+	case http.MethodGet:
+		q := req.URL.Query()
+		p.Query = q.Get("query")
+		p.OperationName = q.Get("operationName")
+		if vars := q.Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &p.Variables); err != nil {
+				problem.Write(w, req, problem.New(http.StatusBadRequest, problem.CodeInvalidJSON, "variables must be a JSON object"))
+				return
+			}
+		}
+	case http.MethodPost:
+		mediatype, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil || mediatype != "application/json" {
+			problem.Write(w, req, problem.New(http.StatusUnsupportedMediaType, problem.CodeUnsupportedMediaType, "expect application/json Content-Type"))
+			return
+		}
+		if err := json.NewDecoder(req.Body).Decode(&p); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				problem.Write(w, req, problem.New(http.StatusRequestEntityTooLarge, problem.CodeBodyTooLarge, fmt.Sprintf("request body is larger than %d bytes", tooLarge.Limit)))
+				return
+			}
+			problem.Write(w, req, problem.New(http.StatusBadRequest, problem.CodeInvalidJSON, "expect a JSON object with query, operationName and variables"))
+			return
+		}
+	default:
+		problem.Write(w, req, problem.New(http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, fmt.Sprintf("expect method GET or POST at /graphql, got %v", req.Method)))
+		return
+	}
+	if p.Query == "" {
+		problem.Write(w, req, problem.New(http.StatusBadRequest, problem.CodeBadRequest, "missing query"))
+		return
+	}
+	if op := operationType(p.Query, p.OperationName); req.Method == http.MethodGet && op != "query" && op != "" {
+		w.Header().Set("Allow", "POST")
+		problem.Write(w, req, problem.New(http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, fmt.Sprintf("expect method POST for a %s", op)))
+		return
+	} else if h.Subscriptions && op != "subscription" {
+		problem.Write(w, req, problem.New(http.StatusNotAcceptable, problem.CodeNotAcceptable, "only subscriptions are streamed"))
+		return
+	}
+
+	if strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+		h.serveStream(w, req, p)
+		return
+	}
+
+	response := h.Schema.Exec(req.Context(), p.Query, p.OperationName, p.Variables)
+	js, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, req, problem.New(http.StatusInternalServerError, problem.CodeInternal, ""))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func (h *Handler) serveStream(w http.ResponseWriter, req *http.Request, p params) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		problem.Write(w, req, problem.New(http.StatusInternalServerError, problem.CodeInternal, "streaming unsupported"))
+		return
+	}
+
+	c, err := h.Schema.Subscribe(req.Context(), p.Query, p.OperationName, p.Variables)
+	if err != nil {
+		problem.Write(w, req, problem.New(http.StatusBadRequest, problem.CodeBadRequest, err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for response := range c {
+		js, err := json.Marshal(response)
+		if err != nil {
+			return
+		}
+		if _, err := fmt.Fprintf(w, "event: next\ndata: %s\n\n", js); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+	fmt.Fprint(w, "event: complete\ndata:\n\n")
+	flusher.Flush()
+}
+
+// operationType returns the type of the operation of query a request runs:
+// "query", "mutation" or "subscription", or "" if it can't tell which one
+// runs, in which case executing the query fails anyway. It only scans the top
+// level of the document, skipping strings and comments.
+func operationType(query, operationName string) string {
+	type operation struct{ typ, name string }
+	var ops []operation
+	depth := 0     // of braces and parentheses
+	keyword := ""  // of the current definition, at the top level
+	named := false // whether the current definition's name was seen
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(query[i:], `"""`):
+			end := strings.Index(strings.ReplaceAll(query[i+3:], `\"""`, "xxxx"), `"""`)
+			if end < 0 {
+				return ""
+			}
+			i += 3 + end + 2
+		case c == '"':
+			for i++; i < len(query) && query[i] != '"'; i++ {
+				if query[i] == '\\' {
+					i++
+				}
+			}
+		case c == '{' || c == '(':
+			if depth == 0 && c == '{' && keyword == "" {
+				ops = append(ops, operation{typ: "query"}) // shorthand
+				keyword = "query"
+			}
+			depth++
+		case c == '}' || c == ')':
+			depth--
+			if depth == 0 && c == '}' {
+				keyword, named = "", false
+			}
+		case c == '@' && depth == 0:
+			named = true // directives follow the name, if any
+		case depth == 0 && nameChar(c) && !('0' <= c && c <= '9'):
+			start := i
+			for i+1 < len(query) && nameChar(query[i+1]) {
+				i++
+			}
+			name := query[start : i+1]
+			isOp := func(keyword string) bool {
+				return keyword == "query" || keyword == "mutation" || keyword == "subscription"
+			}
+			switch {
+			case keyword == "":
+				keyword = name
+				if isOp(name) {
+					ops = append(ops, operation{typ: name})
+				}
+			case !named:
+				named = true
+				if isOp(keyword) {
+					ops[len(ops)-1].name = name
+				}
+			}
+		}
+	}
+
+	if operationName == "" {
+		if len(ops) != 1 {
+			return ""
+		}
+		return ops[0].typ
+	}
+	for _, op := range ops {
+		if op.name == operationName {
+			return op.typ
+		}
+	}
+	return ""
+}
+
+// nameChar reports whether c may be part of a GraphQL name.
+func nameChar(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
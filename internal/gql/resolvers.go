@@ -0,0 +1,377 @@
+// Resolvers for the GraphQL schema.
+
+package gql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"github.com/diorchen/rest-server/internal/feed"
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/middleware"
+	"github.com/diorchen/rest-server/internal/validate"
+	"github.com/diorchen/rest-server/internal/watch"
+)
+
+// refreshInterval is how often subscriptions with time dependent filters are
+// re-evaluated.
+const refreshInterval = time.Minute
+
+var errUnauthorized = errors.New("unauthorized: mutations need basic auth credentials")
+
+type resolver struct {
+	store *groceryItemStore.GroceryItemStore
+	hub   *feed.Hub
+}
+
+func parseID(id graphql.ID) (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil {
+		return 0, fmt.Errorf("expect numeric id, got %q", id)
+	}
+	return n, nil
+}
+
+func toID(id int) graphql.ID {
+	return graphql.ID(strconv.Itoa(id))
+}
+
+func (r *resolver) foods(items []groceryItemStore.FoodItem) []*foodResolver {
+	foods := make([]*foodResolver, len(items))
+	for i := range items {
+		foods[i] = &foodResolver{r: r, food: items[i]}
+	}
+	return foods
+}
+
+func (r *resolver) Food(ctx context.Context, args struct{ Id graphql.ID }) (*foodResolver, error) {
+	id, err := parseID(args.Id)
+	if err != nil {
+		return nil, err
+	}
+	food, err := r.store.GetFood(ctx, id)
+	var notFound *groceryItemStore.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &foodResolver{r: r, food: food}, nil
+}
+
+func (r *resolver) Foods(ctx context.Context) ([]*foodResolver, error) {
+	food, err := r.store.GetAllFood(ctx)
+	return r.foods(food), err
+}
+
+func (r *resolver) FoodsByIngredient(ctx context.Context, args struct{ Ingredient string }) ([]*foodResolver, error) {
+	food, err := r.store.GetFoodByIng(ctx, args.Ingredient)
+	return r.foods(food), err
+}
+
+func (r *resolver) FoodsByExpiration(ctx context.Context, args struct{ Year, Month, Day int32 }) ([]*foodResolver, error) {
+	if args.Month < int32(time.January) || args.Month > int32(time.December) {
+		return nil, fmt.Errorf("expect month between 1 and 12, got %d", args.Month)
+	}
+	food, err := r.store.GetFoodsByExpDate(ctx, int(args.Year), time.Month(args.Month), int(args.Day))
+	return r.foods(food), err
+}
+
+func (r *resolver) FoodsExpiringBefore(ctx context.Context, args struct{ Time graphql.Time }) ([]*foodResolver, error) {
+	food, err := r.store.GetFoodExpiringBefore(ctx, args.Time.Time)
+	return r.foods(food), err
+}
+
+func (r *resolver) Locations(ctx context.Context) ([]*locationResolver, error) {
+	locs, err := r.store.GetAllLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*locationResolver, len(locs))
+	for i := range locs {
+		resolvers[i] = &locationResolver{r: r, loc: locs[i]}
+	}
+	return resolvers, nil
+}
+
+type foodInput struct {
+	Name        string
+	Description *string
+	Ingredients *[]string
+	Expiration  graphql.Time
+	Nutrition   *nutritionInput
+	Category    *string
+	Location    *graphql.ID
+}
+
+type nutritionInput struct {
+	Calories      *int32
+	Protein       *float64
+	Carbohydrates *float64
+	Fat           *float64
+	Fiber         *float64
+}
+
+// authenticated reports whether the request carried valid credentials.
+func authenticated(ctx context.Context) bool {
+	user, _ := ctx.Value(middleware.UserContextKey).(string)
+	return user != ""
+}
+
+func (r *resolver) CreateFood(ctx context.Context, args struct{ Input foodInput }) (*foodResolver, error) {
+	if !authenticated(ctx) {
+		return nil, errUnauthorized
+	}
+
+	in := args.Input
+	food := groceryItemStore.FoodItem{Name: in.Name, Expiration: in.Expiration.Time}
+	if in.Description != nil {
+		food.Description = *in.Description
+	}
+	if in.Ingredients != nil {
+		food.Ingredients = *in.Ingredients
+	}
+	if in.Category != nil {
+		food.Category = *in.Category
+	}
+	if in.Location != nil {
+		loc, err := parseID(*in.Location)
+		if err != nil {
+			return nil, err
+		}
+		food.Location = loc
+	}
+	if n := in.Nutrition; n != nil {
+		if n.Calories != nil {
+			food.Nutrition.Calories = int(*n.Calories)
+		}
+		if n.Protein != nil {
+			food.Nutrition.Protein = *n.Protein
+		}
+		if n.Carbohydrates != nil {
+			food.Nutrition.Carbohydrates = *n.Carbohydrates
+		}
+		if n.Fat != nil {
+			food.Nutrition.Fat = *n.Fat
+		}
+		if n.Fiber != nil {
+			food.Nutrition.Fiber = *n.Fiber
+		}
+	}
+
+	if err := validate.Struct(food); err != nil {
+		return nil, err
+	}
+	id, err := r.store.AddFood(ctx, food)
+	if err != nil {
+		return nil, err
+	}
+	created, err := r.store.GetFood(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &foodResolver{r: r, food: created}, nil
+}
+
+func (r *resolver) DeleteFood(ctx context.Context, args struct{ Id graphql.ID }) (*foodResolver, error) {
+	if !authenticated(ctx) {
+		return nil, errUnauthorized
+	}
+
+	id, err := parseID(args.Id)
+	if err != nil {
+		return nil, err
+	}
+	food, err := r.store.GetFood(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.store.DeleteFood(ctx, id); err != nil {
+		return nil, err
+	}
+	return &foodResolver{r: r, food: food}, nil
+}
+
+type foodFilter struct {
+	Contains           *string
+	Ingredient         *string
+	Category           *string
+	Location           *graphql.ID
+	ExpiresWithinHours *int32
+}
+
+func (f *foodFilter) filter() (watch.Filter, error) {
+	var wf watch.Filter
+	if f == nil {
+		return wf, nil
+	}
+	if f.Contains != nil {
+		wf.Contains = *f.Contains
+	}
+	if f.Ingredient != nil {
+		wf.Ingredient = *f.Ingredient
+	}
+	if f.Category != nil {
+		wf.Category = *f.Category
+	}
+	if f.Location != nil {
+		loc, err := parseID(*f.Location)
+		if err != nil {
+			return wf, err
+		}
+		wf.Location = loc
+	}
+	if f.ExpiresWithinHours != nil {
+		wf.ExpiresWithinHours = int(*f.ExpiresWithinHours)
+	}
+	return wf, wf.Validate()
+}
+
+func (r *resolver) FoodChanged(ctx context.Context, args struct{ Filter *foodFilter }) (<-chan *foodChangeResolver, error) {
+	filter, err := args.Filter.filter()
+	if err != nil {
+		return nil, err
+	}
+
+	// Subscribe before taking the snapshot, so that no change falls in between;
+	// changes the snapshot already has are applied again, and the view catches
+	// up with the later ones.
+	sub, _, open := r.hub.Subscribe(0, 64)
+	if !open {
+		return nil, errors.New("the server is shutting down")
+	}
+	all, err := r.store.GetAllFood(ctx)
+	if err != nil {
+		sub.Close()
+		return nil, err
+	}
+	view, _ := watch.NewView(filter, all, time.Now())
+	c := make(chan *foodChangeResolver)
+
+	go func() {
+		defer close(c)
+		defer sub.Close()
+
+		refresh := time.NewTicker(refreshInterval)
+		defer refresh.Stop()
+
+		var diffs []watch.Diff
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-sub.C:
+				if !ok {
+					// Dropped by the hub; the client has to subscribe again.
+					return
+				}
+				if d, ok := view.Apply(ev, time.Now()); ok {
+					diffs = append(diffs, d)
+				}
+			case <-refresh.C:
+				if filter.TimeDependent() {
+					all, err := r.store.GetAllFood(ctx)
+					if err != nil {
+						return // the subscription was canceled
+					}
+					diffs = append(diffs, view.Refresh(all, time.Now())...)
+				}
+			}
+
+			for _, d := range diffs {
+				select {
+				case c <- &foodChangeResolver{r: r, diff: d}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			diffs = diffs[:0]
+		}
+	}()
+	return c, nil
+}
+
+type foodResolver struct {
+	r    *resolver
+	food groceryItemStore.FoodItem
+}
+
+func (fr *foodResolver) Id() graphql.ID        { return toID(fr.food.Id) }
+func (fr *foodResolver) Name() string          { return fr.food.Name }
+func (fr *foodResolver) Description() string   { return fr.food.Description }
+func (fr *foodResolver) Ingredients() []string { return fr.food.Ingredients }
+func (fr *foodResolver) Category() string      { return fr.food.Category }
+
+func (fr *foodResolver) Expiration() graphql.Time {
+	return graphql.Time{Time: fr.food.Expiration}
+}
+
+func (fr *foodResolver) EffectiveExpiration() graphql.Time {
+	return graphql.Time{Time: fr.food.EffectiveExpiration}
+}
+
+func (fr *foodResolver) Opened() *graphql.Time {
+	if fr.food.Opened == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *fr.food.Opened}
+}
+
+func (fr *foodResolver) Nutrition() *nutritionResolver {
+	return &nutritionResolver{fr.food.Nutrition}
+}
+
+func (fr *foodResolver) Location(ctx context.Context) *locationResolver {
+	if fr.food.Location == 0 {
+		return nil
+	}
+	loc, err := fr.r.store.GetLocation(ctx, fr.food.Location)
+	if err != nil {
+		return nil
+	}
+	return &locationResolver{r: fr.r, loc: loc}
+}
+
+type nutritionResolver struct {
+	n groceryItemStore.Nutrition
+}
+
+func (nr *nutritionResolver) Calories() int32        { return int32(nr.n.Calories) }
+func (nr *nutritionResolver) Protein() float64       { return nr.n.Protein }
+func (nr *nutritionResolver) Carbohydrates() float64 { return nr.n.Carbohydrates }
+func (nr *nutritionResolver) Fat() float64           { return nr.n.Fat }
+func (nr *nutritionResolver) Fiber() float64         { return nr.n.Fiber }
+
+type locationResolver struct {
+	r   *resolver
+	loc groceryItemStore.Location
+}
+
+func (lr *locationResolver) Id() graphql.ID { return toID(lr.loc.Id) }
+func (lr *locationResolver) Name() string   { return lr.loc.Name }
+func (lr *locationResolver) Kind() string   { return lr.loc.Kind }
+
+func (lr *locationResolver) Food(ctx context.Context) []*foodResolver {
+	food, _ := lr.r.store.GetFoodByLocation(ctx, lr.loc.Id)
+	return lr.r.foods(food)
+}
+
+type foodChangeResolver struct {
+	r    *resolver
+	diff watch.Diff
+}
+
+func (cr *foodChangeResolver) Op() string         { return cr.diff.Op }
+func (cr *foodChangeResolver) FoodId() graphql.ID { return toID(cr.diff.FoodId) }
+
+func (cr *foodChangeResolver) Food() *foodResolver {
+	if cr.diff.Food == nil {
+		return nil
+	}
+	return &foodResolver{r: cr.r, food: *cr.diff.Food}
+}
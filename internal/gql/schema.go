@@ -0,0 +1,123 @@
+// GraphQL API over the grocery item store.
+//
+// The schema mirrors the REST endpoints: food items with their nutrition and
+// location, lookups by ingredient and expiration, mutations to create and
+// delete food, and a subscription streaming changes to a filtered set of items.
+
+package gql
+
+import (
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"github.com/diorchen/rest-server/internal/feed"
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+)
+
+const schemaString = `
+	schema {
+		query: Query
+		mutation: Mutation
+		subscription: Subscription
+	}
+
+	scalar Time
+
+	type Query {
+		# A single food item, or null if there's none with this id.
+		food(id: ID!): Food
+		# All the food, in arbitrary order.
+		foods: [Food!]!
+		# Food with the given ingredient.
+		foodsByIngredient(ingredient: String!): [Food!]!
+		# Food whose effective expiration falls on the given day.
+		foodsByExpiration(year: Int!, month: Int!, day: Int!): [Food!]!
+		# Food whose effective expiration is before the given time.
+		foodsExpiringBefore(time: Time!): [Food!]!
+		locations: [Location!]!
+	}
+
+	type Mutation {
+		createFood(input: FoodInput!): Food!
+		# Returns the deleted food.
+		deleteFood(id: ID!): Food!
+	}
+
+	type Subscription {
+		# Changes to the food matching filter; all food if filter is omitted.
+		foodChanged(filter: FoodFilter): FoodChange!
+	}
+
+	type Food {
+		id: ID!
+		name: String!
+		description: String!
+		ingredients: [String!]!
+		expiration: Time!
+		effectiveExpiration: Time!
+		nutrition: Nutrition!
+		category: String!
+		opened: Time
+		location: Location
+	}
+
+	type Nutrition {
+		calories: Int!
+		protein: Float!
+		carbohydrates: Float!
+		fat: Float!
+		fiber: Float!
+	}
+
+	type Location {
+		id: ID!
+		name: String!
+		kind: String!
+		food: [Food!]!
+	}
+
+	# A food item that started matching (added), changed while matching
+	# (changed) or stopped matching (removed) a subscription's filter.
+	type FoodChange {
+		op: String!
+		foodId: ID!
+		# Null for removals.
+		food: Food
+	}
+
+	input FoodInput {
+		name: String!
+		description: String
+		ingredients: [String!]
+		expiration: Time!
+		nutrition: NutritionInput
+		category: String
+		location: ID
+	}
+
+	input NutritionInput {
+		calories: Int
+		protein: Float
+		carbohydrates: Float
+		fat: Float
+		fiber: Float
+	}
+
+	input FoodFilter {
+		contains: String
+		ingredient: String
+		category: String
+		location: ID
+		expiresWithinHours: Int
+	}
+`
+
+// maxDepth is how deeply fields may nest in a query. Food and locations refer
+// to each other, so every level may multiply the reads of the store; this
+// still allows listing the food of a food's location.
+const maxDepth = 5
+
+// NewSchema parses the schema with resolvers backed by store. Subscriptions
+// follow the events of hub.
+func NewSchema(store *groceryItemStore.GroceryItemStore, hub *feed.Hub) (*graphql.Schema, error) {
+	return graphql.ParseSchema(schemaString, &resolver{store: store, hub: hub}, graphql.MaxDepth(maxDepth))
+}
@@ -0,0 +1,302 @@
+// Export of the whole store and import of such exports, for moving an
+// inventory between servers.
+
+package groceryItemStore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/diorchen/rest-server/internal/validate"
+)
+
+// Archive is a copy of everything in a store. IDs are those of the exporting
+// store; food refers to locations, and History to food and locations, by them.
+type Archive struct {
+	Food      []FoodItem             `json:"food"`
+	Locations []Location             `json:"locations"`
+	Rules     []ShelfLifeRule        `json:"rules"`
+	History   map[int][]HistoryEntry `json:"history,omitempty"` // move history by food ID
+}
+
+// ImportMode selects how Import combines an archive with the store's contents.
+type ImportMode string
+
+const (
+	// ImportMerge adds the archive to the store. Food gets new IDs, locations
+	// with the same name and kind as an existing one are reused and rules
+	// replace existing rules of the same category.
+	ImportMerge ImportMode = "merge"
+
+	// ImportReplace deletes everything in the store first and keeps the IDs of
+	// the archive.
+	ImportReplace ImportMode = "replace"
+)
+
+// ImportReport describes what an import did, or would do for a dry run.
+type ImportReport struct {
+	Mode   ImportMode `json:"mode"`
+	DryRun bool       `json:"dryRun"`
+
+	FoodCreated      int `json:"foodCreated"`
+	FoodDeleted      int `json:"foodDeleted"`
+	LocationsCreated int `json:"locationsCreated"`
+	LocationsMatched int `json:"locationsMatched"` // existing locations reused by a merge
+	LocationsDeleted int `json:"locationsDeleted"`
+	RulesCreated     int `json:"rulesCreated"`
+	RulesReplaced    int `json:"rulesReplaced"`
+	RulesDeleted     int `json:"rulesDeleted"`
+
+	// FoodIds and LocationIds map the IDs of the archive to the IDs in the
+	// store.
+	FoodIds     map[int]int `json:"foodIds"`
+	LocationIds map[int]int `json:"locationIds"`
+}
+
+// Export returns a copy of everything in the store, sorted by ID and category.
+func (gis *GroceryItemStore) Export(ctx context.Context) (Archive, error) {
+	span := startSpan(ctx, "Export")
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return Archive{}, err
+	}
+	defer gis.Unlock()
+
+	a := Archive{
+		Food:      make([]FoodItem, 0, len(gis.food)),
+		Locations: make([]Location, 0, len(gis.locations)),
+		Rules:     make([]ShelfLifeRule, 0, len(gis.rules)),
+		History:   make(map[int][]HistoryEntry, len(gis.history)),
+	}
+	for _, food := range gis.food {
+		a.Food = append(a.Food, food)
+	}
+	for _, loc := range gis.locations {
+		a.Locations = append(a.Locations, loc)
+	}
+	for _, rule := range gis.rules {
+		a.Rules = append(a.Rules, rule)
+	}
+	for id, history := range gis.history {
+		a.History[id] = append([]HistoryEntry(nil), history...)
+	}
+	sort.Slice(a.Food, func(i, j int) bool { return a.Food[i].Id < a.Food[j].Id })
+	sort.Slice(a.Locations, func(i, j int) bool { return a.Locations[i].Id < a.Locations[j].Id })
+	sort.Slice(a.Rules, func(i, j int) bool { return a.Rules[i].Category < a.Rules[j].Category })
+	return a, nil
+}
+
+// Import loads an archive into the store according to mode. The archive is
+// checked as a whole first: if any of it is invalid, a ValidationError is
+// returned and the store is left unchanged. With dryRun, the report is
+// computed but nothing is changed either.
+func (gis *GroceryItemStore) Import(ctx context.Context, a Archive, mode ImportMode, dryRun bool) (ImportReport, error) {
+	span := startSpan(ctx, "Import", attribute.String("import.mode", string(mode)), attribute.Bool("import.dry_run", dryRun))
+	defer span.End()
+
+	if mode != ImportMerge && mode != ImportReplace {
+		return ImportReport{}, invalid("unknown import mode %q, expect %q or %q", mode, ImportMerge, ImportReplace)
+	}
+	if err := a.validate(); err != nil {
+		return ImportReport{}, err
+	}
+
+	food := append([]FoodItem(nil), a.Food...)
+	locs := append([]Location(nil), a.Locations...)
+	sort.Slice(food, func(i, j int) bool { return food[i].Id < food[j].Id })
+	sort.Slice(locs, func(i, j int) bool { return locs[i].Id < locs[j].Id })
+
+	if err := gis.lock(ctx); err != nil {
+		return ImportReport{}, err
+	}
+	defer gis.Unlock()
+
+	report := ImportReport{
+		Mode:        mode,
+		DryRun:      dryRun,
+		FoodIds:     make(map[int]int, len(food)),
+		LocationIds: make(map[int]int, len(locs)),
+	}
+
+	// Work out the new IDs first, so that a dry run reports the same IDs a
+	// real import would assign.
+	if mode == ImportReplace {
+		report.FoodDeleted = len(gis.food)
+		report.LocationsDeleted = len(gis.locations)
+		report.RulesDeleted = len(gis.rules)
+		for _, loc := range locs {
+			report.LocationIds[loc.Id] = loc.Id
+		}
+		for _, f := range food {
+			report.FoodIds[f.Id] = f.Id
+		}
+		report.LocationsCreated = len(locs)
+		report.RulesCreated = len(a.Rules)
+	} else {
+		existing := make(map[Location]int, len(gis.locations))
+		for _, loc := range gis.locations {
+			key := Location{Name: loc.Name, Kind: loc.Kind}
+			if id, ok := existing[key]; !ok || loc.Id < id {
+				existing[key] = loc.Id
+			}
+		}
+		nextLocationId := gis.nextLocationId
+		for _, loc := range locs {
+			if id, ok := existing[Location{Name: loc.Name, Kind: loc.Kind}]; ok {
+				report.LocationIds[loc.Id] = id
+				report.LocationsMatched++
+				continue
+			}
+			report.LocationIds[loc.Id] = nextLocationId
+			nextLocationId++
+			report.LocationsCreated++
+		}
+		for i, f := range food {
+			report.FoodIds[f.Id] = gis.nextId + i
+		}
+		for _, rule := range a.Rules {
+			if _, ok := gis.rules[rule.Category]; ok {
+				report.RulesReplaced++
+			} else {
+				report.RulesCreated++
+			}
+		}
+	}
+	report.FoodCreated = len(food)
+	if dryRun {
+		return report, nil
+	}
+
+	if mode == ImportReplace {
+		gis.clear()
+	}
+
+	for _, loc := range locs {
+		id := report.LocationIds[loc.Id]
+		if _, ok := gis.locations[id]; ok {
+			continue // matched an existing location
+		}
+		loc.Id = id
+		gis.locations[id] = loc
+		if id >= gis.nextLocationId {
+			gis.nextLocationId = id + 1
+		}
+		gis.publish(Event{Type: LocationCreated, Location: &loc})
+	}
+
+	for _, rule := range a.Rules {
+		rule := rule
+		gis.rules[rule.Category] = rule
+		gis.publish(Event{Type: RuleUpdated, Rule: &rule})
+		gis.recalculateCategory(rule.Category)
+	}
+
+	for _, f := range food {
+		oldId := f.Id
+		f.Location = report.LocationIds[f.Location]
+		gis.nextId = report.FoodIds[oldId]
+		id := gis.addFood(f)
+		if history, ok := a.History[oldId]; ok {
+			moves := make([]HistoryEntry, len(history))
+			for i, entry := range history {
+				moves[i] = HistoryEntry{Time: entry.Time, From: report.LocationIds[entry.From], To: report.LocationIds[entry.To]}
+			}
+			gis.history[id] = moves
+		}
+	}
+	// Merged food is numbered on from the store's next ID, so nextId is
+	// already past it; replaced IDs may be in any order.
+	for id := range gis.food {
+		if id >= gis.nextId {
+			gis.nextId = id + 1
+		}
+	}
+	return report, nil
+}
+
+// clear deletes everything in the store, publishing a deletion event for each
+// food item, location and rule. The caller must hold the lock.
+func (gis *GroceryItemStore) clear() {
+	for _, food := range gis.food {
+		gis.publishFood(FoodDeleted, food)
+	}
+	for _, loc := range gis.locations {
+		loc := loc
+		gis.publish(Event{Type: LocationDeleted, Location: &loc})
+	}
+	for _, rule := range gis.rules {
+		rule := rule
+		gis.publish(Event{Type: RuleDeleted, Rule: &rule})
+	}
+	gis.food = make(map[int]FoodItem)
+	gis.nextId = 0
+	gis.locations = make(map[int]Location)
+	gis.nextLocationId = 1
+	gis.history = make(map[int][]HistoryEntry)
+	gis.rules = make(map[string]ShelfLifeRule)
+	gis.expired = make(map[int]time.Time)
+}
+
+// validate checks that the IDs of a are unique, that all its references
+// point at food and locations within a, and that its food and rules follow
+// their validate tags.
+func (a Archive) validate() error {
+	locs := make(map[int]bool, len(a.Locations))
+	locs[0] = true // "unassigned" is always valid
+	for _, loc := range a.Locations {
+		if loc.Id <= 0 {
+			return invalid("location %q has id=%d, expect a positive id", loc.Name, loc.Id)
+		}
+		if locs[loc.Id] {
+			return invalid("duplicate location id=%d", loc.Id)
+		}
+		if err := validKind(loc.Kind); err != nil {
+			return err
+		}
+		locs[loc.Id] = true
+	}
+
+	food := make(map[int]bool, len(a.Food))
+	for _, f := range a.Food {
+		if f.Id < 0 {
+			return invalid("food %q has id=%d, expect a non-negative id", f.Name, f.Id)
+		}
+		if food[f.Id] {
+			return invalid("duplicate food id=%d", f.Id)
+		}
+		if !locs[f.Location] {
+			return invalid("food with id=%d is at location id=%d, which isn't in the archive", f.Id, f.Location)
+		}
+		if err := validate.Struct(f); err != nil {
+			return invalid("food with id=%d is invalid: %v", f.Id, err)
+		}
+		food[f.Id] = true
+	}
+
+	categories := make(map[string]bool, len(a.Rules))
+	for _, rule := range a.Rules {
+		if err := validRule(rule); err != nil {
+			return err
+		}
+		if categories[rule.Category] {
+			return invalid("duplicate shelf-life rule for %q", rule.Category)
+		}
+		categories[rule.Category] = true
+	}
+
+	for id, history := range a.History {
+		if !food[id] {
+			return invalid("history of food with id=%d, which isn't in the archive", id)
+		}
+		for _, entry := range history {
+			if !locs[entry.From] || !locs[entry.To] {
+				return invalid("history of food with id=%d refers to a location that isn't in the archive", id)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package groceryItemStore
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newArchiveStore returns a store holding a freezer, a rule and two food items,
+// one of which was moved.
+func newArchiveStore(t *testing.T) *GroceryItemStore {
+	gis := New()
+	gis.now = func() time.Time { return time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC) }
+	fridge, _ := gis.CreateLocation(context.Background(), "Fridge", KindFridge)
+	freezer, _ := gis.CreateLocation(context.Background(), "Freezer", KindFreezer)
+	if err := gis.SetShelfLifeRule(context.Background(), ShelfLifeRule{Category: "bread", FrozenDays: 90}); err != nil {
+		t.Fatal(err)
+	}
+	gis.CreateFood(context.Background(), "Milk", "", []string{"Milk"}, time.Date(2023, 7, 10, 0, 0, 0, 0, time.UTC), Nutrition{})
+	bread, _ := gis.AddFood(context.Background(), FoodItem{Name: "Bread", Category: "bread", Expiration: time.Date(2023, 7, 5, 0, 0, 0, 0, time.UTC), Location: fridge})
+	if err := gis.MoveFood(context.Background(), bread, freezer); err != nil {
+		t.Fatal(err)
+	}
+	return gis
+}
+
+func TestExportImportReplace(t *testing.T) {
+	src := newArchiveStore(t)
+	archive, _ := src.Export(context.Background())
+	if len(archive.Food) != 2 || len(archive.Locations) != 2 || len(archive.Rules) != 1 {
+		t.Fatalf("got archive %+v, want 2 food, 2 locations and 1 rule", archive)
+	}
+
+	dst := New()
+	dst.CreateLocation(context.Background(), "Pantry", KindPantry)
+	dst.CreateFood(context.Background(), "Rice", "", nil, time.Time{}, Nutrition{})
+
+	report, err := dst.Import(context.Background(), archive, ImportReplace, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.FoodDeleted != 1 || report.FoodCreated != 2 || report.LocationsDeleted != 1 || report.LocationsCreated != 2 {
+		t.Errorf("got dry run report %+v", report)
+	}
+	if food, _ := dst.GetAllFood(context.Background()); len(food) != 1 || food[0].Name != "Rice" {
+		t.Fatalf("dry run changed the store, got %v", food)
+	}
+
+	if _, err := dst.Import(context.Background(), archive, ImportReplace, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := dst.Export(context.Background()); !reflect.DeepEqual(got, archive) {
+		t.Errorf("got %+v after replace, want %+v", got, archive)
+	}
+
+	id := dst.CreateFood(context.Background(), "Eggs", "", nil, time.Time{}, Nutrition{})
+	if id != 2 {
+		t.Errorf("new food after replace got id=%d, want 2", id)
+	}
+}
+
+func TestImportMerge(t *testing.T) {
+	archive, _ := newArchiveStore(t).Export(context.Background())
+
+	dst := New()
+	pantry, _ := dst.CreateLocation(context.Background(), "Pantry", KindPantry)
+	freezer, _ := dst.CreateLocation(context.Background(), "Freezer", KindFreezer)
+	dst.CreateFood(context.Background(), "Rice", "", nil, time.Time{}, Nutrition{})
+
+	dry, err := dst.Import(context.Background(), archive, ImportMerge, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := dst.Import(context.Background(), archive, ImportMerge, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dry.DryRun = false
+	if !reflect.DeepEqual(dry, report) {
+		t.Errorf("dry run reported %+v, import %+v", dry, report)
+	}
+
+	if report.FoodCreated != 2 || report.LocationsCreated != 1 || report.LocationsMatched != 1 || report.RulesCreated != 1 {
+		t.Errorf("got report %+v", report)
+	}
+	if got, want := report.LocationIds, map[int]int{1: pantry + 2, 2: freezer}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got location ids %v, want %v", got, want)
+	}
+	if got, want := report.FoodIds, map[int]int{0: 1, 1: 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got food ids %v, want %v", got, want)
+	}
+
+	bread, err := dst.GetFood(context.Background(), report.FoodIds[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bread.Location != freezer {
+		t.Errorf("bread is at location %d, want the existing freezer %d", bread.Location, freezer)
+	}
+	if want := bread.Expiration.AddDate(0, 0, 90); !bread.EffectiveExpiration.Equal(want) {
+		t.Errorf("bread expires %v, want %v", bread.EffectiveExpiration, want)
+	}
+	history, _ := dst.GetFoodHistory(context.Background(), bread.Id)
+	if len(history) != 2 || history[0].To != pantry+2 || history[1].From != pantry+2 || history[1].To != freezer {
+		t.Errorf("got history %+v with remapped locations, want fridge %d then freezer %d", history, pantry+2, freezer)
+	}
+	if food, _ := dst.GetAllFood(context.Background()); len(food) != 3 {
+		t.Errorf("got %d food after merge, want 3", len(food))
+	}
+}
+
+func TestImportInvalid(t *testing.T) {
+	// food returns a valid food item with id, changed by edit.
+	food := func(id int, edit func(*FoodItem)) FoodItem {
+		f := FoodItem{Id: id, Name: "Milk", Expiration: time.Now()}
+		edit(&f)
+		return f
+	}
+	tests := map[string]Archive{
+		"missing location":      {Food: []FoodItem{food(1, func(f *FoodItem) { f.Location = 7 })}},
+		"duplicate food":        {Food: []FoodItem{food(1, func(*FoodItem) {}), food(1, func(*FoodItem) {})}},
+		"blank name":            {Food: []FoodItem{food(1, func(f *FoodItem) { f.Name = " " })}},
+		"no expiration":         {Food: []FoodItem{food(1, func(f *FoodItem) { f.Expiration = time.Time{} })}},
+		"negative calories":     {Food: []FoodItem{food(1, func(f *FoodItem) { f.Nutrition.Calories = -1 })}},
+		"location id 0":         {Locations: []Location{{Id: 0, Name: "Fridge", Kind: KindFridge}}},
+		"unknown kind":          {Locations: []Location{{Id: 1, Name: "Cellar", Kind: "cellar"}}},
+		"invalid rule":          {Rules: []ShelfLifeRule{{Category: "milk", OpenedDays: -1}}},
+		"rule without category": {Rules: []ShelfLifeRule{{FrozenDays: 30}}},
+		"orphan history":        {History: map[int][]HistoryEntry{3: {{To: 1}}}},
+	}
+	for name, archive := range tests {
+		gis := New()
+		gis.CreateFood(context.Background(), "Rice", "", nil, time.Time{}, Nutrition{})
+		_, err := gis.Import(context.Background(), archive, ImportReplace, false)
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%s: got error %v, want ValidationError", name, err)
+		}
+		if len(archive.Food) > 0 && !strings.Contains(err.Error(), "id=1") {
+			t.Errorf("%s: got error %v, want the food named", name, err)
+		}
+		if food, _ := gis.GetAllFood(context.Background()); len(food) != 1 {
+			t.Errorf("%s: failed import changed the store, got %d food", name, len(food))
+		}
+	}
+
+	if _, err := New().Import(context.Background(), Archive{}, "append", false); err == nil {
+		t.Error("unknown mode, got no error")
+	}
+}
@@ -0,0 +1,42 @@
+// Typed errors returned by the store, so callers can tell why an operation
+// failed with errors.As.
+
+package groceryItemStore
+
+import "fmt"
+
+// NotFoundError is returned when the food item, location or shelf-life rule an
+// operation is about doesn't exist.
+type NotFoundError struct {
+	msg string
+}
+
+func (e *NotFoundError) Error() string { return e.msg }
+
+// ConflictError is returned when an operation conflicts with the current state
+// of the store, such as deleting a location that still holds food.
+type ConflictError struct {
+	msg string
+}
+
+func (e *ConflictError) Error() string { return e.msg }
+
+// ValidationError is returned when the arguments of an operation are invalid,
+// including references to locations that don't exist.
+type ValidationError struct {
+	msg string
+}
+
+func (e *ValidationError) Error() string { return e.msg }
+
+func notFound(format string, args ...interface{}) error {
+	return &NotFoundError{msg: fmt.Sprintf(format, args...)}
+}
+
+func conflict(format string, args ...interface{}) error {
+	return &ConflictError{msg: fmt.Sprintf(format, args...)}
+}
+
+func invalid(format string, args ...interface{}) error {
+	return &ValidationError{msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,34 @@
+package groceryItemStore
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestErrorTypes(t *testing.T) {
+	gis := New()
+	fridge, _ := gis.CreateLocation(context.Background(), "Fridge", KindFridge)
+	gis.AddFood(context.Background(), FoodItem{Name: "Milk", Location: fridge})
+
+	var notFound *NotFoundError
+	if _, err := gis.GetFood(context.Background(), 100); !errors.As(err, &notFound) {
+		t.Errorf("get missing food: got %v, want NotFoundError", err)
+	}
+	if err := gis.DeleteShelfLifeRule(context.Background(), "dairy"); !errors.As(err, &notFound) {
+		t.Errorf("delete missing rule: got %v, want NotFoundError", err)
+	}
+
+	var conflict *ConflictError
+	if err := gis.DeleteLocation(context.Background(), fridge); !errors.As(err, &conflict) {
+		t.Errorf("delete location holding food: got %v, want ConflictError", err)
+	}
+
+	var invalid *ValidationError
+	if _, err := gis.AddFood(context.Background(), FoodItem{Name: "Milk", Location: fridge + 1}); !errors.As(err, &invalid) {
+		t.Errorf("add food to missing location: got %v, want ValidationError", err)
+	}
+	if _, err := gis.CreateLocation(context.Background(), "Cellar", "cellar"); !errors.As(err, &invalid) {
+		t.Errorf("create location of unknown kind: got %v, want ValidationError", err)
+	}
+}
@@ -0,0 +1,118 @@
+// Events published by the store on every mutation, so other parts of the
+// server can react to changes without polling.
+
+package groceryItemStore
+
+import (
+	"context"
+	"time"
+)
+
+// Types of Event published by the store.
+const (
+	FoodCreated     = "food.created"
+	FoodUpdated     = "food.updated"
+	FoodDeleted     = "food.deleted"
+	FoodExpired     = "food.expired"
+	LocationCreated = "location.created"
+	LocationUpdated = "location.updated"
+	LocationDeleted = "location.deleted"
+	RuleUpdated     = "rule.updated"
+	RuleDeleted     = "rule.deleted"
+)
+
+// EventTypes lists the types of Event.
+var EventTypes = []string{
+	FoodCreated, FoodUpdated, FoodDeleted, FoodExpired,
+	LocationCreated, LocationUpdated, LocationDeleted,
+	RuleUpdated, RuleDeleted,
+}
+
+// Event describes a single change to the store. Depending on Type, one of
+// Food, Location or Rule holds the changed (or deleted) value.
+type Event struct {
+	Id       int            `json:"id"` // increases by one with every event
+	Type     string         `json:"type"`
+	Time     time.Time      `json:"time"`
+	Food     *FoodItem      `json:"food,omitempty"`
+	Location *Location      `json:"location,omitempty"`
+	Rule     *ShelfLifeRule `json:"rule,omitempty"`
+}
+
+// Subscribe registers fn to be called with every event published by the store,
+// in order. fn is called while the store is locked: it must return quickly and
+// must not call back into the store. The returned function unsubscribes fn.
+func (gis *GroceryItemStore) Subscribe(fn func(Event)) (unsubscribe func()) {
+	gis.Lock()
+	defer gis.Unlock()
+
+	id := gis.nextSubscriberId
+	gis.nextSubscriberId++
+	gis.subscribers[id] = fn
+	return func() {
+		gis.Lock()
+		defer gis.Unlock()
+		delete(gis.subscribers, id)
+	}
+}
+
+// Close prepares the store for the server shutting down: it drops all
+// subscribers, so that no events are published to parts of the server that
+// are already stopped. The store is kept in memory, so there is nothing to
+// flush; it can still be read and written.
+func (gis *GroceryItemStore) Close(ctx context.Context) error {
+	span := startSpan(ctx, "Close")
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return err
+	}
+	defer gis.Unlock()
+
+	gis.subscribers = make(map[int]func(Event))
+	return nil
+}
+
+// publish sends an event to all subscribers. Every change of the store is
+// published, so publish also keeps track of when the store was last modified.
+// The caller must hold the lock.
+func (gis *GroceryItemStore) publish(ev Event) {
+	gis.lastEventId++
+	ev.Id = gis.lastEventId
+	ev.Time = gis.now()
+	if ev.Type != FoodExpired { // food expiring changes nothing stored
+		gis.modified.Store(ev.Time.UnixNano())
+	}
+	for _, fn := range gis.subscribers {
+		fn(ev)
+	}
+}
+
+func (gis *GroceryItemStore) publishFood(typ string, food FoodItem) {
+	gis.publish(Event{Type: typ, Food: &food})
+}
+
+// LastModified returns when the contents of the store last changed, or when
+// the store was created if they never did. It doesn't wait for the lock, so
+// that handlers can check it before queueing for the store.
+func (gis *GroceryItemStore) LastModified() time.Time {
+	return time.Unix(0, gis.modified.Load())
+}
+
+// ReportExpired publishes a FoodExpired event for every food item whose
+// effective expiration has passed since the last call. Each item is reported
+// once per effective expiration.
+func (gis *GroceryItemStore) ReportExpired() {
+	gis.Lock()
+	defer gis.Unlock()
+
+	now := gis.now()
+	for id, food := range gis.food {
+		exp := food.EffectiveExpiration
+		if exp.IsZero() || exp.After(now) || gis.expired[id].Equal(exp) {
+			continue
+		}
+		gis.expired[id] = exp
+		gis.publishFood(FoodExpired, food)
+	}
+}
@@ -0,0 +1,80 @@
+package groceryItemStore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	gis := New()
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	gis.now = func() time.Time { return now }
+
+	var events []Event
+	unsubscribe := gis.Subscribe(func(ev Event) {
+		events = append(events, ev)
+	})
+
+	fridge, _ := gis.CreateLocation(context.Background(), "Fridge", KindFridge)
+	milk, _ := gis.AddFood(context.Background(), FoodItem{Name: "Milk", Expiration: now.Add(time.Hour), Location: fridge})
+	gis.OpenFood(context.Background(), milk)
+	now = now.Add(2 * time.Hour)
+	gis.ReportExpired()
+	gis.ReportExpired()
+	gis.DeleteFood(context.Background(), milk)
+	unsubscribe()
+	gis.DeleteLocation(context.Background(), fridge)
+
+	want := []string{LocationCreated, FoodCreated, FoodUpdated, FoodExpired, FoodDeleted}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events %v, want %v", len(events), events, want)
+	}
+	for i, ev := range events {
+		if ev.Type != want[i] {
+			t.Errorf("events[%d].Type = %s, want %s", i, ev.Type, want[i])
+		}
+		if ev.Id != i+1 {
+			t.Errorf("events[%d].Id = %d, want %d", i, ev.Id, i+1)
+		}
+	}
+	if events[1].Food == nil || events[1].Food.Id != milk {
+		t.Errorf("got created event %+v, want milk", events[1])
+	}
+}
+
+func TestCloseDropsSubscribers(t *testing.T) {
+	gis := New()
+	published := 0
+	gis.Subscribe(func(Event) { published++ })
+
+	if err := gis.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	gis.CreateFood(context.Background(), "Milk", "", nil, time.Time{}, Nutrition{})
+	if published != 0 {
+		t.Errorf("got %d events after Close, want none", published)
+	}
+}
+
+func TestLastModified(t *testing.T) {
+	gis := New()
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	gis.now = func() time.Time { return now }
+
+	now = now.Add(time.Hour)
+	milk := gis.CreateFood(context.Background(), "Milk", "", nil, now.Add(time.Minute), Nutrition{})
+	if got := gis.LastModified(); !got.Equal(now) {
+		t.Errorf("after creating food: got %v, want %v", got, now)
+	}
+
+	created := now
+	now = now.Add(time.Hour)
+	gis.ReportExpired()
+	if err := gis.DeleteFood(context.Background(), milk+1); err == nil {
+		t.Fatal("deleted missing food")
+	}
+	if got := gis.LastModified(); !got.Equal(created) {
+		t.Errorf("after expiring and failing to delete: got %v, want %v", got, created)
+	}
+}
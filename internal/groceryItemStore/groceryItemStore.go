@@ -2,117 +2,224 @@
 package groceryItemStore
 
 import (
-	"fmt"
-	"sync" // synchronization primitives for managing concurrent access to shared resources
+	"context"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// FoodItem is a food item in the store. The validate tags bound the items
+// callers may add; the store only checks them on Import, the APIs before
+// adding food.
 type FoodItem struct {
 	Id   			int       `json:"id"`
-	Name 			string    `json:"name"`
-	Description 	string	  `json:"description"`
-	Ingredients 	[]string  `json:"ingredients"` // slice of stirngs
-	Expiration   	time.Time `json:"expiration"`
+	Name 			string    `json:"name" validate:"required,max=100"`
+	Description 	string	  `json:"description" validate:"max=1000"`
+	Ingredients 	[]string  `json:"ingredients" validate:"unique,max=50,dive,required,max=100"` // slice of stirngs
+	Expiration   	time.Time `json:"expiration" validate:"required,within=50"`
 	Nutrition		Nutrition `json:"nutrition"`
+	Location		int       `json:"location,omitempty" validate:"min=0"` // id of the Location holding the item, 0 if unassigned
+	Category		string    `json:"category,omitempty" validate:"max=50"` // selects the ShelfLifeRule applied to the item
+	Opened			*time.Time `json:"opened,omitempty"` // when the item was opened, nil if still sealed
+
+	// EffectiveExpiration is Expiration adjusted by the item's shelf-life rule
+	// for its opened state and location. It is computed by the store.
+	EffectiveExpiration time.Time `json:"effectiveExpiration"`
 }
 
+// Nutrition per serving; the validate tags bound the values requests may set.
 type Nutrition struct {
-	Calories		int			`json:"calories"`
-	Protein			float64		`json:"protein"`
-	Carbohydrates	float64		`json:"carbohydrates"`
-	Fat				float64		`json:"fat"`
-	Fiber			float64		`json:"fiber"`
+	Calories		int			`json:"calories" validate:"min=0,max=10000"`
+	Protein			float64		`json:"protein" validate:"min=0,max=1000"`
+	Carbohydrates	float64		`json:"carbohydrates" validate:"min=0,max=1000"`
+	Fat				float64		`json:"fat" validate:"min=0,max=1000"`
+	Fiber			float64		`json:"fiber" validate:"min=0,max=1000"`
 }
 // GroceryItemStore is a simple in-memory database of food items; GroceryItemStore methods are
-// safe to call concurrently.
+// safe to call concurrently. Operations take the context of the request they
+// serve and are traced as its children. Operations returning an error give up
+// with the context's error if it's done before they get to run.
 type GroceryItemStore struct { // collection of food items
-	sync.Mutex // mutual exclusion lock to protect shared resources from concurrent access by multiple goroutines
+	sem chan struct{} // mutual exclusion: the goroutine that sent the one value it holds has the lock
 
 	food  map[int]FoodItem // each groceryItem associated with ID by mapping keys of type 'int' to values of type 'FoodItem'
 	nextId int // ensures ID uniqueness, keeps track of next available ID to be assigned
+
+	locations      map[int]Location       // storage locations by ID
+	nextLocationId int                    // location IDs start at 1 so that 0 can mean "unassigned"
+	history        map[int][]HistoryEntry // per food item log of moves between locations
+	rules          map[string]ShelfLifeRule // shelf-life rules by food category
+	expired        map[int]time.Time        // expiration already reported by ReportExpired, by food ID
+
+	subscribers      map[int]func(Event) // event subscribers by subscription ID
+	nextSubscriberId int
+	lastEventId      int
+	modified         atomic.Int64 // when the contents last changed, in Unix nanoseconds
+
+	now func() time.Time // clock used to timestamp history, replaceable in tests
+
+	lockStats lockStats
 }
 
 func New() *GroceryItemStore { // Func 'New' returns pointer to (*) struct GroceryItemStore
 	gis := &GroceryItemStore{} // new var 'gis' assigned to newly allocated 'GroceryItemStore' object (empty), initialized with {}.
 	gis.food = make(map[int]FoodItem) // initializes 'food' of the 'GroceryItemStore' as an empty map, providing a storage container for grocery items.
 	gis.nextId = 0
+	gis.locations = make(map[int]Location)
+	gis.nextLocationId = 1
+	gis.history = make(map[int][]HistoryEntry)
+	gis.rules = make(map[string]ShelfLifeRule)
+	gis.expired = make(map[int]time.Time)
+	gis.subscribers = make(map[int]func(Event))
+	gis.now = time.Now
+	gis.modified.Store(gis.now().UnixNano())
+	gis.sem = make(chan struct{}, 1)
 	return gis
 }
 
 // CreateFood creates a new food in the store.
 	// method receiver, indicates CreateFood is associated with GroceryItemStore object, gis = name of receiver variable
-func (gis *GroceryItemStore) CreateFood(name string, description string, ingredients []string, expiration time.Time, nutrition Nutrition) int {
+func (gis *GroceryItemStore) CreateFood(ctx context.Context, name string, description string, ingredients []string, expiration time.Time, nutrition Nutrition) int {
+	span := startSpan(ctx, "CreateFood")
+	defer span.End()
+
 	gis.Lock() // lock synchronizes access to resource 'item' variable
 	defer gis.Unlock() // ensure lock is released when function returns
 
-	food := FoodItem{ // Creates new FoodItem and initializes fields
-		Id:   gis.nextId,	
+	return gis.addFood(FoodItem{ // Creates new FoodItem and initializes fields
 		Name: name,
 		Description: description,
-		Ingredients: make([]string, len(ingredients)),
+		Ingredients: ingredients,
 		Expiration: expiration,
-		Nutrition: nutrition}
+		Nutrition: nutrition})
+}
+
+// AddFood creates a new food in the store from the given item, ignoring its Id.
+// If the item names a Location that doesn't exist, an error is returned and
+// nothing is stored.
+func (gis *GroceryItemStore) AddFood(ctx context.Context, food FoodItem) (int, error) {
+	span := startSpan(ctx, "AddFood")
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer gis.Unlock()
+
+	if food.Location != 0 {
+		if _, ok := gis.locations[food.Location]; !ok {
+			return 0, invalid("location with id=%d not found", food.Location)
+		}
+	}
+	return gis.addFood(food), nil
+}
 
+// addFood stores food under the next available ID and returns that ID. The
+// caller must hold the lock.
+func (gis *GroceryItemStore) addFood(food FoodItem) int {
+	food.Id = gis.nextId
+	ingredients := food.Ingredients
+	food.Ingredients = make([]string, len(ingredients))
 	copy(food.Ingredients, ingredients)
+	food.EffectiveExpiration = gis.effectiveExpiration(food)
 
 	gis.food[gis.nextId] = food // associates new created food with new ID
 	gis.nextId++ // increments new ID
+	if food.Location != 0 {
+		gis.recordMove(food.Id, 0, food.Location)
+	}
+	gis.publishFood(FoodCreated, food)
 	return food.Id
 }
 
 // GetFood retrieves a food from the store, by id. If no such id exists, an
 // error is returned.
-func (gis *GroceryItemStore) GetFood(id int) (FoodItem, error) {
-	gis.Lock()
+func (gis *GroceryItemStore) GetFood(ctx context.Context, id int) (FoodItem, error) {
+	span := startSpan(ctx, "GetFood", attribute.Int("food.id", id))
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return FoodItem{}, err
+	}
 	defer gis.Unlock()
 
 	food, ok := gis.food[id] // food = key, ok = boolean flag
 	if ok {
 		return food, nil
 	} else {
-		return FoodItem{}, fmt.Errorf("food with id=%d not found", id)
+		return FoodItem{}, notFound("food with id=%d not found", id)
 	}
 }
 
 // DeleteFood deletes the food with the given id. If no such id exists, an error
 // is returned.
-func (gis *GroceryItemStore) DeleteFood(id int) error {
-	gis.Lock()
+func (gis *GroceryItemStore) DeleteFood(ctx context.Context, id int) error {
+	span := startSpan(ctx, "DeleteFood", attribute.Int("food.id", id))
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return err
+	}
 	defer gis.Unlock()
 
-	if _, ok := gis.food[id]; !ok { // check if food item with given id exists in store.food map, if not, return error
-		return fmt.Errorf("food with id=%d not found", id)
+	food, ok := gis.food[id]
+	if !ok { // check if food item with given id exists in store.food map, if not, return error
+		return notFound("food with id=%d not found", id)
 	}
 
 	delete(gis.food, id)
+	delete(gis.history, id)
+	delete(gis.expired, id)
+	gis.publishFood(FoodDeleted, food)
 	return nil
 }
 
 // DeleteAllFood deletes all food in the store.
-func (gis *GroceryItemStore) DeleteAllFood() error {
-	gis.Lock()
+func (gis *GroceryItemStore) DeleteAllFood(ctx context.Context) error {
+	span := startSpan(ctx, "DeleteAllFood")
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return err
+	}
 	defer gis.Unlock()
 
+	for _, food := range gis.food {
+		gis.publishFood(FoodDeleted, food)
+	}
 	gis.food = make(map[int]FoodItem) // reset the store.food map to an empty map
+	gis.history = make(map[int][]HistoryEntry)
+	gis.expired = make(map[int]time.Time)
 	return nil // return nil to indicate successful deletion
 }
 
 // GetAllFood returns all the food in the store, in arbitrary order.
-func (gis *GroceryItemStore) GetAllFood() []FoodItem {
-	gis.Lock()
+func (gis *GroceryItemStore) GetAllFood(ctx context.Context) ([]FoodItem, error) {
+	span := startSpan(ctx, "GetAllFood")
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return nil, err
+	}
 	defer gis.Unlock()
 
 	allFood := make([]FoodItem, 0, len(gis.food))
 	for _, food := range gis.food {
 		allFood = append(allFood, food)
 	}
-	return allFood
+	return allFood, nil
 }
 
 // GetFoodByIng returns all the food that have the given ingredients, in arbitrary
 // order.
-func (gis *GroceryItemStore) GetFoodByIng(ingredients string) []FoodItem {
-	gis.Lock()
+func (gis *GroceryItemStore) GetFoodByIng(ctx context.Context, ingredients string) ([]FoodItem, error) {
+	span := startSpan(ctx, "GetFoodByIng", attribute.String("food.ingredient", ingredients))
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return nil, err
+	}
 	defer gis.Unlock()
 
 	var foods []FoodItem
@@ -126,23 +233,48 @@ foodloop:
 			}
 		}
 	}
-	return foods
+	return foods, nil
 }
 
-// GetFoodByExpDate returns all the food that have the given exp date, in
-// arbitrary order.
-func (gis *GroceryItemStore) GetFoodsByExpDate(year int, month time.Month, day int) []FoodItem {
-	gis.Lock()
+// GetFoodByExpDate returns all the food that have the given effective exp date,
+// in arbitrary order.
+func (gis *GroceryItemStore) GetFoodsByExpDate(ctx context.Context, year int, month time.Month, day int) ([]FoodItem, error) {
+	span := startSpan(ctx, "GetFoodsByExpDate")
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return nil, err
+	}
 	defer gis.Unlock()
 
 	var foods []FoodItem
 
 	for _, food := range gis.food {
-		y, m, d := food.Expiration.Date()
+		y, m, d := food.EffectiveExpiration.Date()
 		if y == year && m == month && d == day {
 			foods = append(foods, food)
 		}
 	}
 
-	return foods
-}
\ No newline at end of file
+	return foods, nil
+}
+
+// GetFoodExpiringBefore returns all the food whose effective expiration is set
+// and earlier than t, in arbitrary order.
+func (gis *GroceryItemStore) GetFoodExpiringBefore(ctx context.Context, t time.Time) ([]FoodItem, error) {
+	span := startSpan(ctx, "GetFoodExpiringBefore")
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer gis.Unlock()
+
+	var foods []FoodItem
+	for _, food := range gis.food {
+		if !food.EffectiveExpiration.IsZero() && food.EffectiveExpiration.Before(t) {
+			foods = append(foods, food)
+		}
+	}
+	return foods, nil
+}
@@ -2,6 +2,7 @@
 package groceryItemStore
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -9,10 +10,10 @@ import (
 func TestCreateAndGet(t *testing.T) {
 	// Create a store and a single food.
 	gis := New()
-	id := gis.CreateFood("Strawberries", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
+	id := gis.CreateFood(context.Background(), "Strawberries", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
 
 	// We should be able to retrieve this food by ID, but nothing with other IDs.
-	food, err := gis.GetFood(id)
+	food, err := gis.GetFood(context.Background(), id)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -25,19 +26,19 @@ func TestCreateAndGet(t *testing.T) {
 	}
 
 	// Asking for all food, we only get the one we put in.
-	allFood := gis.GetAllFood()
+	allFood, _ := gis.GetAllFood(context.Background())
 	if len(allFood) != 1 || allFood[0].Id != id {
 		t.Errorf("got len(allFood)=%d, allFood[0].Id=%d; want 1, %d", len(allFood), allFood[0].Id, id)
 	}
 
-	_, err = gis.GetFood(id + 1)
+	_, err = gis.GetFood(context.Background(), id + 1)
 	if err == nil {
 		t.Fatal("got nil, want error")
 	}
 
 	// Add another food. Expect to find two tasks in the store.
-	gis.CreateFood("Bananas", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
-	allFood2 := gis.GetAllFood()
+	gis.CreateFood(context.Background(), "Bananas", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
+	allFood2, _ := gis.GetAllFood(context.Background())
 	if len(allFood2) != 2 {
 		t.Errorf("got len(allFood2)=%d; want 2", len(allFood2))
 	}
@@ -45,35 +46,35 @@ func TestCreateAndGet(t *testing.T) {
 
 func TestDelete(t *testing.T) {
 	gis := New()
-	id1 := gis.CreateFood("Apples", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
-	id2 := gis.CreateFood("Kiwis", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
+	id1 := gis.CreateFood(context.Background(), "Apples", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
+	id2 := gis.CreateFood(context.Background(), "Kiwis", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
 
-	if err := gis.DeleteFood(id1 + 1001); err == nil {
+	if err := gis.DeleteFood(context.Background(), id1 + 1001); err == nil {
 		t.Fatalf("delete food id=%d, got no error; want error", id1+1001)
 	}
 
-	if err := gis.DeleteFood(id1); err != nil {
+	if err := gis.DeleteFood(context.Background(), id1); err != nil {
 		t.Fatal(err)
 	}
-	if err := gis.DeleteFood(id1); err == nil {
+	if err := gis.DeleteFood(context.Background(), id1); err == nil {
 		t.Fatalf("delete food id=%d, got no error; want error", id1)
 	}
 
-	if err := gis.DeleteFood(id2); err != nil {
+	if err := gis.DeleteFood(context.Background(), id2); err != nil {
 		t.Fatal(err)
 	}
 }
 
 func TestDeleteAll(t *testing.T) {
 	gis := New()
-	gis.CreateFood("Apples", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
-	gis.CreateFood("Kiwis", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
+	gis.CreateFood(context.Background(), "Apples", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
+	gis.CreateFood(context.Background(), "Kiwis", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
 
-	if err := gis.DeleteAllFood(); err != nil {
+	if err := gis.DeleteAllFood(context.Background()); err != nil {
 		t.Fatal(err)
 	}
 
-	food := gis.GetAllFood()
+	food, _ := gis.GetAllFood(context.Background())
 	if len(food) > 0 {
 		t.Fatalf("want no food remaining; got %v", food)
 	}
@@ -81,12 +82,12 @@ func TestDeleteAll(t *testing.T) {
 
 func TestGetFoodByIng(t *testing.T) {
 	gis := New()
-	gis.CreateFood("Apples", "From Costco", []string{"Apples"}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
-	gis.CreateFood("Kiwis", "From Costco", []string{"Kiwis"}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
-	gis.CreateFood("Strawberries", "From Costco", []string{"Strawberries"}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
-	gis.CreateFood("Guava", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
-	gis.CreateFood("Pineapple", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
-	gis.CreateFood("Oranges", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
+	gis.CreateFood(context.Background(), "Apples", "From Costco", []string{"Apples"}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
+	gis.CreateFood(context.Background(), "Kiwis", "From Costco", []string{"Kiwis"}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
+	gis.CreateFood(context.Background(), "Strawberries", "From Costco", []string{"Strawberries"}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
+	gis.CreateFood(context.Background(), "Guava", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
+	gis.CreateFood(context.Background(), "Pineapple", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
+	gis.CreateFood(context.Background(), "Oranges", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
 
 	var tests = []struct {
 		Ingredients     string
@@ -99,7 +100,8 @@ func TestGetFoodByIng(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.Ingredients, func(t *testing.T) {
-			numByIng := len(gis.GetFoodByIng(tt.Ingredients))
+			food, _ := gis.GetFoodByIng(context.Background(), tt.Ingredients)
+			numByIng := len(food)
 
 			if numByIng != tt.wantNum {
 				t.Errorf("got %v, want %v", numByIng, tt.wantNum)
@@ -119,16 +121,16 @@ func TestGetFoodsByExpDate(t *testing.T) {
 	}
 
 	gis := New()
-	gis.CreateFood("Apples", "From Costco", []string{"Apples"}, mustParseDate("2020-Dec-01"), Nutrition{})
-	gis.CreateFood("Kiwis", "From Costco", []string{"Kiwis"}, mustParseDate("2000-Dec-21"), Nutrition{})
-	gis.CreateFood("Strawberries", "From Costco", []string{"Strawberries"}, mustParseDate("2020-Dec-01"), Nutrition{})
-	gis.CreateFood("Guava", "From Costco", []string{}, mustParseDate("2000-Dec-21"), Nutrition{})
-	gis.CreateFood("Pineapple", "From Costco", []string{}, mustParseDate("2000-Dec-21"), Nutrition{})
-	gis.CreateFood("Oranges", "From Costco", []string{}, mustParseDate("1991-Jan-01"), Nutrition{})
+	gis.CreateFood(context.Background(), "Apples", "From Costco", []string{"Apples"}, mustParseDate("2020-Dec-01"), Nutrition{})
+	gis.CreateFood(context.Background(), "Kiwis", "From Costco", []string{"Kiwis"}, mustParseDate("2000-Dec-21"), Nutrition{})
+	gis.CreateFood(context.Background(), "Strawberries", "From Costco", []string{"Strawberries"}, mustParseDate("2020-Dec-01"), Nutrition{})
+	gis.CreateFood(context.Background(), "Guava", "From Costco", []string{}, mustParseDate("2000-Dec-21"), Nutrition{})
+	gis.CreateFood(context.Background(), "Pineapple", "From Costco", []string{}, mustParseDate("2000-Dec-21"), Nutrition{})
+	gis.CreateFood(context.Background(), "Oranges", "From Costco", []string{}, mustParseDate("1991-Jan-01"), Nutrition{})
 
 	// Check a single task can be fetched.
 	y, m, d := mustParseDate("1991-Jan-01").Date()
-	food1 := gis.GetFoodsByExpDate(y, m, d)
+	food1, _ := gis.GetFoodsByExpDate(context.Background(), y, m, d)
 	if len(food1) != 1 {
 		t.Errorf("got len=%d, want 1", len(food1))
 	}
@@ -150,11 +152,24 @@ func TestGetFoodsByExpDate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.date, func(t *testing.T) {
 			y, m, d := mustParseDate(tt.date).Date()
-			numByDate := len(gis.GetFoodsByExpDate(y, m, d))
+			food, _ := gis.GetFoodsByExpDate(context.Background(), y, m, d)
+			numByDate := len(food)
 
 			if numByDate != tt.wantNum {
 				t.Errorf("got %v, want %v", numByDate, tt.wantNum)
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestGetFoodExpiringBefore(t *testing.T) {
+	gis := New()
+	gis.CreateFood(context.Background(), "Milk", "From Costco", []string{}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
+	gis.CreateFood(context.Background(), "Rice", "From Costco", []string{}, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), Nutrition{})
+	gis.CreateFood(context.Background(), "Salt", "From Costco", []string{}, time.Time{}, Nutrition{})
+
+	food, _ := gis.GetFoodExpiringBefore(context.Background(), time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC))
+	if len(food) != 1 || food[0].Name != "Milk" {
+		t.Errorf("got %v, want only Milk", food)
+	}
+}
@@ -0,0 +1,236 @@
+// Storage locations (fridge, freezer, pantry) and the move history of food items.
+
+package groceryItemStore
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Kinds of storage location a Location can be.
+const (
+	KindFridge  = "fridge"
+	KindFreezer = "freezer"
+	KindPantry  = "pantry"
+)
+
+// Location is a place where food items are stored, e.g. "Garage freezer".
+type Location struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind"` // one of KindFridge, KindFreezer or KindPantry
+}
+
+// HistoryEntry records a single move of a food item between locations. A
+// location of 0 means the item wasn't stored anywhere (before the move) or was
+// taken out (after it).
+type HistoryEntry struct {
+	Time time.Time `json:"time"`
+	From int       `json:"from"`
+	To   int       `json:"to"`
+}
+
+func validKind(kind string) error {
+	switch kind {
+	case KindFridge, KindFreezer, KindPantry:
+		return nil
+	}
+	return invalid("unknown location kind %q, expect %q, %q or %q", kind, KindFridge, KindFreezer, KindPantry)
+}
+
+// CreateLocation creates a new location in the store and returns its ID.
+func (gis *GroceryItemStore) CreateLocation(ctx context.Context, name string, kind string) (int, error) {
+	span := startSpan(ctx, "CreateLocation")
+	defer span.End()
+
+	if err := validKind(kind); err != nil {
+		return 0, err
+	}
+
+	if err := gis.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer gis.Unlock()
+
+	loc := Location{Id: gis.nextLocationId, Name: name, Kind: kind}
+	gis.locations[loc.Id] = loc
+	gis.nextLocationId++
+	gis.publish(Event{Type: LocationCreated, Location: &loc})
+	return loc.Id, nil
+}
+
+// GetLocation retrieves a location from the store, by id. If no such id exists,
+// an error is returned.
+func (gis *GroceryItemStore) GetLocation(ctx context.Context, id int) (Location, error) {
+	span := startSpan(ctx, "GetLocation", attribute.Int("location.id", id))
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return Location{}, err
+	}
+	defer gis.Unlock()
+
+	loc, ok := gis.locations[id]
+	if !ok {
+		return Location{}, notFound("location with id=%d not found", id)
+	}
+	return loc, nil
+}
+
+// GetAllLocations returns all the locations in the store, in arbitrary order.
+func (gis *GroceryItemStore) GetAllLocations(ctx context.Context) ([]Location, error) {
+	span := startSpan(ctx, "GetAllLocations")
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer gis.Unlock()
+
+	locs := make([]Location, 0, len(gis.locations))
+	for _, loc := range gis.locations {
+		locs = append(locs, loc)
+	}
+	return locs, nil
+}
+
+// UpdateLocation renames the location with the given id and changes its kind.
+func (gis *GroceryItemStore) UpdateLocation(ctx context.Context, id int, name string, kind string) error {
+	span := startSpan(ctx, "UpdateLocation", attribute.Int("location.id", id))
+	defer span.End()
+
+	if err := validKind(kind); err != nil {
+		return err
+	}
+
+	if err := gis.lock(ctx); err != nil {
+		return err
+	}
+	defer gis.Unlock()
+
+	if _, ok := gis.locations[id]; !ok {
+		return notFound("location with id=%d not found", id)
+	}
+	loc := Location{Id: id, Name: name, Kind: kind}
+	gis.locations[id] = loc
+	gis.publish(Event{Type: LocationUpdated, Location: &loc})
+
+	// Turning a fridge into a freezer (or back) changes how long its food lasts.
+	for _, food := range gis.food {
+		if food.Location == id {
+			gis.recalculate(food)
+		}
+	}
+	return nil
+}
+
+// DeleteLocation deletes the location with the given id. Locations still
+// holding food can't be deleted; their food has to be moved elsewhere first.
+func (gis *GroceryItemStore) DeleteLocation(ctx context.Context, id int) error {
+	span := startSpan(ctx, "DeleteLocation", attribute.Int("location.id", id))
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return err
+	}
+	defer gis.Unlock()
+
+	loc, ok := gis.locations[id]
+	if !ok {
+		return notFound("location with id=%d not found", id)
+	}
+	for _, food := range gis.food {
+		if food.Location == id {
+			return conflict("location with id=%d still holds food", id)
+		}
+	}
+
+	delete(gis.locations, id)
+	gis.publish(Event{Type: LocationDeleted, Location: &loc})
+	return nil
+}
+
+// GetFoodByLocation returns all the food stored at the given location, in
+// arbitrary order.
+func (gis *GroceryItemStore) GetFoodByLocation(ctx context.Context, id int) ([]FoodItem, error) {
+	span := startSpan(ctx, "GetFoodByLocation", attribute.Int("location.id", id))
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer gis.Unlock()
+
+	if _, ok := gis.locations[id]; !ok {
+		return nil, notFound("location with id=%d not found", id)
+	}
+
+	var foods []FoodItem
+	for _, food := range gis.food {
+		if food.Location == id {
+			foods = append(foods, food)
+		}
+	}
+	return foods, nil
+}
+
+// MoveFood moves the food with the given id to another location and records
+// the move in the food's history. Moving to location 0 takes the food out of
+// any location.
+func (gis *GroceryItemStore) MoveFood(ctx context.Context, id int, location int) error {
+	span := startSpan(ctx, "MoveFood", attribute.Int("food.id", id), attribute.Int("location.id", location))
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return err
+	}
+	defer gis.Unlock()
+
+	food, ok := gis.food[id]
+	if !ok {
+		return notFound("food with id=%d not found", id)
+	}
+	if location != 0 {
+		if _, ok := gis.locations[location]; !ok {
+			return invalid("location with id=%d not found", location)
+		}
+	}
+	if food.Location == location {
+		return nil
+	}
+
+	gis.recordMove(id, food.Location, location)
+	food.Location = location
+	food.EffectiveExpiration = gis.effectiveExpiration(food)
+	gis.food[id] = food
+	gis.publishFood(FoodUpdated, food)
+	return nil
+}
+
+// GetFoodHistory returns the moves of the food with the given id, oldest
+// first.
+func (gis *GroceryItemStore) GetFoodHistory(ctx context.Context, id int) ([]HistoryEntry, error) {
+	span := startSpan(ctx, "GetFoodHistory", attribute.Int("food.id", id))
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer gis.Unlock()
+
+	if _, ok := gis.food[id]; !ok {
+		return nil, notFound("food with id=%d not found", id)
+	}
+
+	history := make([]HistoryEntry, len(gis.history[id]))
+	copy(history, gis.history[id])
+	return history, nil
+}
+
+// recordMove appends a move to the history of food id. The caller must hold
+// the lock.
+func (gis *GroceryItemStore) recordMove(id int, from int, to int) {
+	gis.history[id] = append(gis.history[id], HistoryEntry{Time: gis.now(), From: from, To: to})
+}
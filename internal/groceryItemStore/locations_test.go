@@ -0,0 +1,107 @@
+package groceryItemStore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLocations(t *testing.T) {
+	gis := New()
+	fridge, err := gis.CreateLocation(context.Background(), "Kitchen fridge", KindFridge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gis.CreateLocation(context.Background(), "Basement", "cellar"); err == nil {
+		t.Fatal("create location with unknown kind, got no error; want error")
+	}
+
+	loc, err := gis.GetLocation(context.Background(), fridge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.Name != "Kitchen fridge" || loc.Kind != KindFridge {
+		t.Errorf("got %+v, want Kitchen fridge/%s", loc, KindFridge)
+	}
+
+	if err := gis.UpdateLocation(context.Background(), fridge, "Fridge", KindFridge); err != nil {
+		t.Fatal(err)
+	}
+	if locs, _ := gis.GetAllLocations(context.Background()); len(locs) != 1 || locs[0].Name != "Fridge" {
+		t.Errorf("got %v, want one location named Fridge", locs)
+	}
+
+	id, err := gis.AddFood(context.Background(), FoodItem{Name: "Milk", Location: fridge})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gis.AddFood(context.Background(), FoodItem{Name: "Milk", Location: fridge + 100}); err == nil {
+		t.Fatal("add food to missing location, got no error; want error")
+	}
+
+	if err := gis.DeleteLocation(context.Background(), fridge); err == nil {
+		t.Fatal("delete location holding food, got no error; want error")
+	}
+	if err := gis.DeleteFood(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+	if err := gis.DeleteLocation(context.Background(), fridge); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gis.GetLocation(context.Background(), fridge); err == nil {
+		t.Fatal("get deleted location, got no error; want error")
+	}
+}
+
+func TestMoveFood(t *testing.T) {
+	gis := New()
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	gis.now = func() time.Time { return now }
+
+	fridge, _ := gis.CreateLocation(context.Background(), "Fridge", KindFridge)
+	freezer, _ := gis.CreateLocation(context.Background(), "Freezer", KindFreezer)
+	id, err := gis.AddFood(context.Background(), FoodItem{Name: "Bread", Location: fridge})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(time.Hour)
+	if err := gis.MoveFood(context.Background(), id, freezer); err != nil {
+		t.Fatal(err)
+	}
+	if err := gis.MoveFood(context.Background(), id, freezer+100); err == nil {
+		t.Fatal("move food to missing location, got no error; want error")
+	}
+	if err := gis.MoveFood(context.Background(), id+100, freezer); err == nil {
+		t.Fatal("move missing food, got no error; want error")
+	}
+
+	inFridge, err := gis.GetFoodByLocation(context.Background(), fridge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inFreezer, err := gis.GetFoodByLocation(context.Background(), freezer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inFridge) != 0 || len(inFreezer) != 1 || inFreezer[0].Id != id {
+		t.Errorf("got fridge=%v freezer=%v; want bread in freezer only", inFridge, inFreezer)
+	}
+
+	history, err := gis.GetFoodHistory(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []HistoryEntry{
+		{Time: time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), From: 0, To: fridge},
+		{Time: time.Date(2023, 7, 1, 1, 0, 0, 0, time.UTC), From: fridge, To: freezer},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("got history %v, want %v", history, want)
+	}
+	for i := range want {
+		if history[i] != want[i] {
+			t.Errorf("history[%d] = %v, want %v", i, history[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Locking of the store. The lock is a channel rather than a sync.Mutex so
+// that operations can stop waiting for it when their context is done.
+
+package groceryItemStore
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// lockStats is updated on every acquisition; it's atomic since it's updated
+// while the lock isn't held yet.
+type lockStats struct {
+	acquisitions atomic.Int64
+	waitNanos    atomic.Int64
+}
+
+// Lock locks the store, keeping track of how long it waited for the lock.
+func (gis *GroceryItemStore) Lock() {
+	start := time.Now()
+	gis.sem <- struct{}{}
+	gis.acquired(start)
+}
+
+// Unlock unlocks the store.
+func (gis *GroceryItemStore) Unlock() {
+	<-gis.sem
+}
+
+// lock is like Lock, but gives up with the error of ctx if ctx is done before
+// the lock is free.
+func (gis *GroceryItemStore) lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("waiting for the store: %w", err)
+	}
+	start := time.Now()
+	select {
+	case gis.sem <- struct{}{}:
+		gis.acquired(start)
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for the store: %w", ctx.Err())
+	}
+}
+
+func (gis *GroceryItemStore) acquired(start time.Time) {
+	gis.lockStats.acquisitions.Add(1)
+	gis.lockStats.waitNanos.Add(int64(time.Since(start)))
+}
@@ -0,0 +1,30 @@
+package groceryItemStore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockHonorsContext(t *testing.T) {
+	gis := New()
+	id, _ := gis.AddFood(context.Background(), FoodItem{Name: "milk"})
+
+	gis.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := gis.GetFood(ctx, id); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("store locked past the deadline, got error %v, want the deadline's", err)
+	}
+	gis.Unlock()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := gis.DeleteFood(canceled, id); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled context, got error %v", err)
+	}
+	if _, err := gis.GetFood(context.Background(), id); err != nil {
+		t.Errorf("food deleted after all: %v", err)
+	}
+}
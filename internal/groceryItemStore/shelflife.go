@@ -0,0 +1,166 @@
+// Shelf-life rules that adjust a food item's expiration by its category,
+// opened state and storage location.
+
+package groceryItemStore
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/diorchen/rest-server/internal/validate"
+)
+
+// ShelfLifeRule describes how long food of a category keeps once opened or
+// frozen. Zero values mean the rule doesn't change the expiration in that
+// situation.
+type ShelfLifeRule struct {
+	Category string `json:"category" validate:"required,max=50"`
+
+	// OpenedDays is how many days the food keeps after being opened; the
+	// effective expiration is never later than the opening time plus this.
+	OpenedDays int `json:"openedDays" validate:"min=0"`
+
+	// FrozenDays is how many days are added to the expiration while the food
+	// is stored in a freezer.
+	FrozenDays int `json:"frozenDays" validate:"min=0"`
+}
+
+const day = 24 * time.Hour
+
+// effectiveExpiration computes the effective expiration of food from its
+// category's rule. The caller must hold the lock.
+func (gis *GroceryItemStore) effectiveExpiration(food FoodItem) time.Time {
+	exp := food.Expiration
+	rule, ok := gis.rules[food.Category]
+	if !ok || food.Category == "" {
+		return exp
+	}
+
+	if rule.FrozenDays > 0 && gis.locations[food.Location].Kind == KindFreezer {
+		exp = exp.Add(time.Duration(rule.FrozenDays) * day)
+	}
+	if rule.OpenedDays > 0 && food.Opened != nil {
+		if opened := food.Opened.Add(time.Duration(rule.OpenedDays) * day); opened.Before(exp) {
+			exp = opened
+		}
+	}
+	return exp
+}
+
+// recalculate recomputes the effective expiration of food and publishes an
+// update if it changed. The caller must hold the lock.
+func (gis *GroceryItemStore) recalculate(food FoodItem) {
+	exp := gis.effectiveExpiration(food)
+	if exp.Equal(food.EffectiveExpiration) {
+		return
+	}
+	food.EffectiveExpiration = exp
+	gis.food[food.Id] = food
+	gis.publishFood(FoodUpdated, food)
+}
+
+// recalculateCategory recomputes the effective expiration of all the food in
+// category. The caller must hold the lock.
+func (gis *GroceryItemStore) recalculateCategory(category string) {
+	for _, food := range gis.food {
+		if food.Category == category {
+			gis.recalculate(food)
+		}
+	}
+}
+
+func validRule(rule ShelfLifeRule) error {
+	if err := validate.Struct(rule); err != nil {
+		return invalid("shelf-life rule for %q is invalid: %v", rule.Category, err)
+	}
+	return nil
+}
+
+// SetShelfLifeRule creates or replaces the rule for rule.Category and
+// recalculates the expiration of all the food in that category.
+func (gis *GroceryItemStore) SetShelfLifeRule(ctx context.Context, rule ShelfLifeRule) error {
+	span := startSpan(ctx, "SetShelfLifeRule", attribute.String("rule.category", rule.Category))
+	defer span.End()
+
+	if err := validRule(rule); err != nil {
+		return err
+	}
+
+	if err := gis.lock(ctx); err != nil {
+		return err
+	}
+	defer gis.Unlock()
+
+	gis.rules[rule.Category] = rule
+	gis.publish(Event{Type: RuleUpdated, Rule: &rule})
+	gis.recalculateCategory(rule.Category)
+	return nil
+}
+
+// GetShelfLifeRules returns all the shelf-life rules, in arbitrary order.
+func (gis *GroceryItemStore) GetShelfLifeRules(ctx context.Context) ([]ShelfLifeRule, error) {
+	span := startSpan(ctx, "GetShelfLifeRules")
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer gis.Unlock()
+
+	rules := make([]ShelfLifeRule, 0, len(gis.rules))
+	for _, rule := range gis.rules {
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// DeleteShelfLifeRule deletes the rule for category, resetting the food in that
+// category to its plain expiration. If no such rule exists, an error is
+// returned.
+func (gis *GroceryItemStore) DeleteShelfLifeRule(ctx context.Context, category string) error {
+	span := startSpan(ctx, "DeleteShelfLifeRule", attribute.String("rule.category", category))
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return err
+	}
+	defer gis.Unlock()
+
+	rule, ok := gis.rules[category]
+	if !ok {
+		return notFound("shelf-life rule for %q not found", category)
+	}
+	delete(gis.rules, category)
+	gis.publish(Event{Type: RuleDeleted, Rule: &rule})
+	gis.recalculateCategory(category)
+	return nil
+}
+
+// OpenFood marks the food with the given id as opened now and recalculates its
+// expiration. Opening food that is already open keeps the original time.
+func (gis *GroceryItemStore) OpenFood(ctx context.Context, id int) error {
+	span := startSpan(ctx, "OpenFood", attribute.Int("food.id", id))
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return err
+	}
+	defer gis.Unlock()
+
+	food, ok := gis.food[id]
+	if !ok {
+		return notFound("food with id=%d not found", id)
+	}
+	if food.Opened != nil {
+		return nil
+	}
+
+	opened := gis.now()
+	food.Opened = &opened
+	food.EffectiveExpiration = gis.effectiveExpiration(food)
+	gis.food[id] = food
+	gis.publishFood(FoodUpdated, food)
+	return nil
+}
@@ -0,0 +1,67 @@
+package groceryItemStore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestShelfLifeRules(t *testing.T) {
+	gis := New()
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	gis.now = func() time.Time { return now }
+	label := time.Date(2023, 7, 20, 0, 0, 0, 0, time.UTC)
+
+	fridge, _ := gis.CreateLocation(context.Background(), "Fridge", KindFridge)
+	freezer, _ := gis.CreateLocation(context.Background(), "Freezer", KindFreezer)
+	if err := gis.SetShelfLifeRule(context.Background(), ShelfLifeRule{Category: "milk", OpenedDays: 7}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gis.SetShelfLifeRule(context.Background(), ShelfLifeRule{Category: "bread", FrozenDays: 90}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gis.SetShelfLifeRule(context.Background(), ShelfLifeRule{Category: "eggs", OpenedDays: -1}); err == nil {
+		t.Fatal("set rule with negative days, got no error; want error")
+	}
+
+	milk, _ := gis.AddFood(context.Background(), FoodItem{Name: "Milk", Category: "milk", Expiration: label, Location: fridge})
+	bread, _ := gis.AddFood(context.Background(), FoodItem{Name: "Bread", Category: "bread", Expiration: label, Location: fridge})
+
+	effective := func(id int) time.Time {
+		food, err := gis.GetFood(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return food.EffectiveExpiration
+	}
+
+	if got := effective(milk); !got.Equal(label) {
+		t.Errorf("sealed milk expires %v, want %v", got, label)
+	}
+	if err := gis.OpenFood(context.Background(), milk); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := effective(milk), now.AddDate(0, 0, 7); !got.Equal(want) {
+		t.Errorf("opened milk expires %v, want %v", got, want)
+	}
+
+	if err := gis.MoveFood(context.Background(), bread, freezer); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := effective(bread), label.AddDate(0, 0, 90); !got.Equal(want) {
+		t.Errorf("frozen bread expires %v, want %v", got, want)
+	}
+	if foods, _ := gis.GetFoodsByExpDate(context.Background(), 2023, time.October, 18); len(foods) != 1 || foods[0].Id != bread {
+		t.Errorf("got %v expiring on 2023-10-18, want the bread", foods)
+	}
+
+	if err := gis.DeleteShelfLifeRule(context.Background(), "bread"); err != nil {
+		t.Fatal(err)
+	}
+	if got := effective(bread); !got.Equal(label) {
+		t.Errorf("bread without rule expires %v, want %v", got, label)
+	}
+	if err := gis.DeleteShelfLifeRule(context.Background(), "bread"); err == nil {
+		t.Fatal("delete missing rule, got no error; want error")
+	}
+}
@@ -0,0 +1,59 @@
+// Statistics of the store for monitoring: its size and how long callers wait
+// for its lock.
+
+package groceryItemStore
+
+import (
+	"context"
+	"time"
+)
+
+// Stats counts what is in the store.
+type Stats struct {
+	Food      int // food items
+	Expired   int // food items past their effective expiration
+	Locations int
+	Rules     int
+}
+
+// LockStats describes the contention on the store's lock.
+type LockStats struct {
+	Acquisitions int64         // times the lock was taken
+	Wait         time.Duration // total time spent waiting for it
+}
+
+// LockStats returns the lock statistics since the store was created.
+func (gis *GroceryItemStore) LockStats() LockStats {
+	return LockStats{
+		Acquisitions: gis.lockStats.acquisitions.Load(),
+		Wait:         time.Duration(gis.lockStats.waitNanos.Load()),
+	}
+}
+
+// Stats counts the contents of the store.
+func (gis *GroceryItemStore) Stats() Stats {
+	gis.Lock()
+	defer gis.Unlock()
+
+	now := gis.now()
+	stats := Stats{Food: len(gis.food), Locations: len(gis.locations), Rules: len(gis.rules)}
+	for _, food := range gis.food {
+		if !food.EffectiveExpiration.IsZero() && !food.EffectiveExpiration.After(now) {
+			stats.Expired++
+		}
+	}
+	return stats
+}
+
+// Ready reports whether the store can serve requests. It's kept in memory, so
+// it's ready as soon as its lock can be taken.
+func (gis *GroceryItemStore) Ready(ctx context.Context) error {
+	span := startSpan(ctx, "Ready")
+	defer span.End()
+
+	if err := gis.lock(ctx); err != nil {
+		return err
+	}
+	gis.Unlock()
+	return nil
+}
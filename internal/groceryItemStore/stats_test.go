@@ -0,0 +1,27 @@
+package groceryItemStore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	gis := New()
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	gis.now = func() time.Time { return now }
+	gis.CreateLocation(context.Background(), "Fridge", KindFridge)
+	gis.CreateFood(context.Background(), "Milk", "", []string{"Milk"}, now.Add(-time.Hour), Nutrition{})
+	gis.CreateFood(context.Background(), "Bread", "", []string{"Flour"}, now.Add(time.Hour), Nutrition{})
+	if err := gis.SetShelfLifeRule(context.Background(), ShelfLifeRule{Category: "bread", FrozenDays: 90}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Stats{Food: 2, Expired: 1, Locations: 1, Rules: 1}
+	if got := gis.Stats(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if lock := gis.LockStats(); lock.Acquisitions == 0 {
+		t.Errorf("got %+v, want the lock counted", lock)
+	}
+}
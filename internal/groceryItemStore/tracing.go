@@ -0,0 +1,23 @@
+// Tracing of store operations with OpenTelemetry.
+
+package groceryItemStore
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of store operations with the global tracer
+// provider, so they are dropped unless the program installs one.
+var tracer = otel.Tracer("github.com/diorchen/rest-server/internal/groceryItemStore")
+
+// startSpan starts the span of the store operation op, as a child of the span
+// in ctx if there is one. Operations don't start further spans, so the
+// span's context isn't returned.
+func startSpan(ctx context.Context, op string, attrs ...attribute.KeyValue) trace.Span {
+	_, span := tracer.Start(ctx, "GroceryItemStore."+op, trace.WithAttributes(attrs...))
+	return span
+}
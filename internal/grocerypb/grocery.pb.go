@@ -0,0 +1,785 @@
+// gRPC API over the grocery item store, mirroring the REST FoodItem and
+// Nutrition types.
+//
+// Regenerate the Go code after changing this file with:
+//
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//          --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//          internal/grocerypb/grocery.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: internal/grocerypb/grocery.proto
+
+package grocerypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Nutrition struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Calories      int32                  `protobuf:"varint,1,opt,name=calories,proto3" json:"calories,omitempty"`
+	Protein       float64                `protobuf:"fixed64,2,opt,name=protein,proto3" json:"protein,omitempty"`
+	Carbohydrates float64                `protobuf:"fixed64,3,opt,name=carbohydrates,proto3" json:"carbohydrates,omitempty"`
+	Fat           float64                `protobuf:"fixed64,4,opt,name=fat,proto3" json:"fat,omitempty"`
+	Fiber         float64                `protobuf:"fixed64,5,opt,name=fiber,proto3" json:"fiber,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Nutrition) Reset() {
+	*x = Nutrition{}
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Nutrition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Nutrition) ProtoMessage() {}
+
+func (x *Nutrition) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Nutrition.ProtoReflect.Descriptor instead.
+func (*Nutrition) Descriptor() ([]byte, []int) {
+	return file_internal_grocerypb_grocery_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Nutrition) GetCalories() int32 {
+	if x != nil {
+		return x.Calories
+	}
+	return 0
+}
+
+func (x *Nutrition) GetProtein() float64 {
+	if x != nil {
+		return x.Protein
+	}
+	return 0
+}
+
+func (x *Nutrition) GetCarbohydrates() float64 {
+	if x != nil {
+		return x.Carbohydrates
+	}
+	return 0
+}
+
+func (x *Nutrition) GetFat() float64 {
+	if x != nil {
+		return x.Fat
+	}
+	return 0
+}
+
+func (x *Nutrition) GetFiber() float64 {
+	if x != nil {
+		return x.Fiber
+	}
+	return 0
+}
+
+type FoodItem struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name        string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Ingredients []string               `protobuf:"bytes,4,rep,name=ingredients,proto3" json:"ingredients,omitempty"`
+	Expiration  *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=expiration,proto3" json:"expiration,omitempty"`
+	Nutrition   *Nutrition             `protobuf:"bytes,6,opt,name=nutrition,proto3" json:"nutrition,omitempty"`
+	// ID of the storage location, 0 if unassigned.
+	Location int64  `protobuf:"varint,7,opt,name=location,proto3" json:"location,omitempty"`
+	Category string `protobuf:"bytes,8,opt,name=category,proto3" json:"category,omitempty"`
+	// Unset while the item is sealed.
+	Opened              *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=opened,proto3" json:"opened,omitempty"`
+	EffectiveExpiration *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=effective_expiration,json=effectiveExpiration,proto3" json:"effective_expiration,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *FoodItem) Reset() {
+	*x = FoodItem{}
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FoodItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FoodItem) ProtoMessage() {}
+
+func (x *FoodItem) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FoodItem.ProtoReflect.Descriptor instead.
+func (*FoodItem) Descriptor() ([]byte, []int) {
+	return file_internal_grocerypb_grocery_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FoodItem) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *FoodItem) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FoodItem) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *FoodItem) GetIngredients() []string {
+	if x != nil {
+		return x.Ingredients
+	}
+	return nil
+}
+
+func (x *FoodItem) GetExpiration() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Expiration
+	}
+	return nil
+}
+
+func (x *FoodItem) GetNutrition() *Nutrition {
+	if x != nil {
+		return x.Nutrition
+	}
+	return nil
+}
+
+func (x *FoodItem) GetLocation() int64 {
+	if x != nil {
+		return x.Location
+	}
+	return 0
+}
+
+func (x *FoodItem) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *FoodItem) GetOpened() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Opened
+	}
+	return nil
+}
+
+func (x *FoodItem) GetEffectiveExpiration() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EffectiveExpiration
+	}
+	return nil
+}
+
+type CreateFoodRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Ingredients   []string               `protobuf:"bytes,3,rep,name=ingredients,proto3" json:"ingredients,omitempty"`
+	Expiration    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expiration,proto3" json:"expiration,omitempty"`
+	Nutrition     *Nutrition             `protobuf:"bytes,5,opt,name=nutrition,proto3" json:"nutrition,omitempty"`
+	Location      int64                  `protobuf:"varint,6,opt,name=location,proto3" json:"location,omitempty"`
+	Category      string                 `protobuf:"bytes,7,opt,name=category,proto3" json:"category,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateFoodRequest) Reset() {
+	*x = CreateFoodRequest{}
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateFoodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateFoodRequest) ProtoMessage() {}
+
+func (x *CreateFoodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateFoodRequest.ProtoReflect.Descriptor instead.
+func (*CreateFoodRequest) Descriptor() ([]byte, []int) {
+	return file_internal_grocerypb_grocery_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateFoodRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateFoodRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreateFoodRequest) GetIngredients() []string {
+	if x != nil {
+		return x.Ingredients
+	}
+	return nil
+}
+
+func (x *CreateFoodRequest) GetExpiration() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Expiration
+	}
+	return nil
+}
+
+func (x *CreateFoodRequest) GetNutrition() *Nutrition {
+	if x != nil {
+		return x.Nutrition
+	}
+	return nil
+}
+
+func (x *CreateFoodRequest) GetLocation() int64 {
+	if x != nil {
+		return x.Location
+	}
+	return 0
+}
+
+func (x *CreateFoodRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+type CreateFoodResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateFoodResponse) Reset() {
+	*x = CreateFoodResponse{}
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateFoodResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateFoodResponse) ProtoMessage() {}
+
+func (x *CreateFoodResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateFoodResponse.ProtoReflect.Descriptor instead.
+func (*CreateFoodResponse) Descriptor() ([]byte, []int) {
+	return file_internal_grocerypb_grocery_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateFoodResponse) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type GetFoodRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFoodRequest) Reset() {
+	*x = GetFoodRequest{}
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFoodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFoodRequest) ProtoMessage() {}
+
+func (x *GetFoodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFoodRequest.ProtoReflect.Descriptor instead.
+func (*GetFoodRequest) Descriptor() ([]byte, []int) {
+	return file_internal_grocerypb_grocery_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetFoodRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListFoodRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only list food with this ingredient, if set.
+	Ingredient    string `protobuf:"bytes,1,opt,name=ingredient,proto3" json:"ingredient,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFoodRequest) Reset() {
+	*x = ListFoodRequest{}
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFoodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFoodRequest) ProtoMessage() {}
+
+func (x *ListFoodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFoodRequest.ProtoReflect.Descriptor instead.
+func (*ListFoodRequest) Descriptor() ([]byte, []int) {
+	return file_internal_grocerypb_grocery_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListFoodRequest) GetIngredient() string {
+	if x != nil {
+		return x.Ingredient
+	}
+	return ""
+}
+
+type DeleteFoodRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteFoodRequest) Reset() {
+	*x = DeleteFoodRequest{}
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteFoodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteFoodRequest) ProtoMessage() {}
+
+func (x *DeleteFoodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteFoodRequest.ProtoReflect.Descriptor instead.
+func (*DeleteFoodRequest) Descriptor() ([]byte, []int) {
+	return file_internal_grocerypb_grocery_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteFoodRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeleteFoodResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteFoodResponse) Reset() {
+	*x = DeleteFoodResponse{}
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteFoodResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteFoodResponse) ProtoMessage() {}
+
+func (x *DeleteFoodResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteFoodResponse.ProtoReflect.Descriptor instead.
+func (*DeleteFoodResponse) Descriptor() ([]byte, []int) {
+	return file_internal_grocerypb_grocery_proto_rawDescGZIP(), []int{7}
+}
+
+type WatchFoodRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Resume after this event ID, replaying buffered events; 0 starts with new
+	// events only.
+	LastEventId   int64 `protobuf:"varint,1,opt,name=last_event_id,json=lastEventId,proto3" json:"last_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchFoodRequest) Reset() {
+	*x = WatchFoodRequest{}
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchFoodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchFoodRequest) ProtoMessage() {}
+
+func (x *WatchFoodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchFoodRequest.ProtoReflect.Descriptor instead.
+func (*WatchFoodRequest) Descriptor() ([]byte, []int) {
+	return file_internal_grocerypb_grocery_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WatchFoodRequest) GetLastEventId() int64 {
+	if x != nil {
+		return x.LastEventId
+	}
+	return 0
+}
+
+type FoodEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// One of "food.created", "food.updated", "food.deleted" or "food.expired".
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	Food          *FoodItem              `protobuf:"bytes,4,opt,name=food,proto3" json:"food,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FoodEvent) Reset() {
+	*x = FoodEvent{}
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FoodEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FoodEvent) ProtoMessage() {}
+
+func (x *FoodEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grocerypb_grocery_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FoodEvent.ProtoReflect.Descriptor instead.
+func (*FoodEvent) Descriptor() ([]byte, []int) {
+	return file_internal_grocerypb_grocery_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *FoodEvent) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *FoodEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *FoodEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *FoodEvent) GetFood() *FoodItem {
+	if x != nil {
+		return x.Food
+	}
+	return nil
+}
+
+var File_internal_grocerypb_grocery_proto protoreflect.FileDescriptor
+
+const file_internal_grocerypb_grocery_proto_rawDesc = "" +
+	"\n" +
+	" internal/grocerypb/grocery.proto\x12\n" +
+	"grocery.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x8f\x01\n" +
+	"\tNutrition\x12\x1a\n" +
+	"\bcalories\x18\x01 \x01(\x05R\bcalories\x12\x18\n" +
+	"\aprotein\x18\x02 \x01(\x01R\aprotein\x12$\n" +
+	"\rcarbohydrates\x18\x03 \x01(\x01R\rcarbohydrates\x12\x10\n" +
+	"\x03fat\x18\x04 \x01(\x01R\x03fat\x12\x14\n" +
+	"\x05fiber\x18\x05 \x01(\x01R\x05fiber\"\x9e\x03\n" +
+	"\bFoodItem\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12 \n" +
+	"\vingredients\x18\x04 \x03(\tR\vingredients\x12:\n" +
+	"\n" +
+	"expiration\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"expiration\x123\n" +
+	"\tnutrition\x18\x06 \x01(\v2\x15.grocery.v1.NutritionR\tnutrition\x12\x1a\n" +
+	"\blocation\x18\a \x01(\x03R\blocation\x12\x1a\n" +
+	"\bcategory\x18\b \x01(\tR\bcategory\x122\n" +
+	"\x06opened\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x06opened\x12M\n" +
+	"\x14effective_expiration\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\x13effectiveExpiration\"\x94\x02\n" +
+	"\x11CreateFoodRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12 \n" +
+	"\vingredients\x18\x03 \x03(\tR\vingredients\x12:\n" +
+	"\n" +
+	"expiration\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"expiration\x123\n" +
+	"\tnutrition\x18\x05 \x01(\v2\x15.grocery.v1.NutritionR\tnutrition\x12\x1a\n" +
+	"\blocation\x18\x06 \x01(\x03R\blocation\x12\x1a\n" +
+	"\bcategory\x18\a \x01(\tR\bcategory\"$\n" +
+	"\x12CreateFoodResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\" \n" +
+	"\x0eGetFoodRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"1\n" +
+	"\x0fListFoodRequest\x12\x1e\n" +
+	"\n" +
+	"ingredient\x18\x01 \x01(\tR\n" +
+	"ingredient\"#\n" +
+	"\x11DeleteFoodRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\x14\n" +
+	"\x12DeleteFoodResponse\"6\n" +
+	"\x10WatchFoodRequest\x12\"\n" +
+	"\rlast_event_id\x18\x01 \x01(\x03R\vlastEventId\"\x89\x01\n" +
+	"\tFoodEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12(\n" +
+	"\x04food\x18\x04 \x01(\v2\x14.grocery.v1.FoodItemR\x04food2\xec\x02\n" +
+	"\x0eGroceryService\x12K\n" +
+	"\n" +
+	"CreateFood\x12\x1d.grocery.v1.CreateFoodRequest\x1a\x1e.grocery.v1.CreateFoodResponse\x12;\n" +
+	"\aGetFood\x12\x1a.grocery.v1.GetFoodRequest\x1a\x14.grocery.v1.FoodItem\x12?\n" +
+	"\bListFood\x12\x1b.grocery.v1.ListFoodRequest\x1a\x14.grocery.v1.FoodItem0\x01\x12K\n" +
+	"\n" +
+	"DeleteFood\x12\x1d.grocery.v1.DeleteFoodRequest\x1a\x1e.grocery.v1.DeleteFoodResponse\x12B\n" +
+	"\tWatchFood\x12\x1c.grocery.v1.WatchFoodRequest\x1a\x15.grocery.v1.FoodEvent0\x01B4Z2github.com/diorchen/rest-server/internal/grocerypbb\x06proto3"
+
+var (
+	file_internal_grocerypb_grocery_proto_rawDescOnce sync.Once
+	file_internal_grocerypb_grocery_proto_rawDescData []byte
+)
+
+func file_internal_grocerypb_grocery_proto_rawDescGZIP() []byte {
+	file_internal_grocerypb_grocery_proto_rawDescOnce.Do(func() {
+		file_internal_grocerypb_grocery_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_internal_grocerypb_grocery_proto_rawDesc), len(file_internal_grocerypb_grocery_proto_rawDesc)))
+	})
+	return file_internal_grocerypb_grocery_proto_rawDescData
+}
+
+var file_internal_grocerypb_grocery_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_internal_grocerypb_grocery_proto_goTypes = []any{
+	(*Nutrition)(nil),             // 0: grocery.v1.Nutrition
+	(*FoodItem)(nil),              // 1: grocery.v1.FoodItem
+	(*CreateFoodRequest)(nil),     // 2: grocery.v1.CreateFoodRequest
+	(*CreateFoodResponse)(nil),    // 3: grocery.v1.CreateFoodResponse
+	(*GetFoodRequest)(nil),        // 4: grocery.v1.GetFoodRequest
+	(*ListFoodRequest)(nil),       // 5: grocery.v1.ListFoodRequest
+	(*DeleteFoodRequest)(nil),     // 6: grocery.v1.DeleteFoodRequest
+	(*DeleteFoodResponse)(nil),    // 7: grocery.v1.DeleteFoodResponse
+	(*WatchFoodRequest)(nil),      // 8: grocery.v1.WatchFoodRequest
+	(*FoodEvent)(nil),             // 9: grocery.v1.FoodEvent
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+}
+var file_internal_grocerypb_grocery_proto_depIdxs = []int32{
+	10, // 0: grocery.v1.FoodItem.expiration:type_name -> google.protobuf.Timestamp
+	0,  // 1: grocery.v1.FoodItem.nutrition:type_name -> grocery.v1.Nutrition
+	10, // 2: grocery.v1.FoodItem.opened:type_name -> google.protobuf.Timestamp
+	10, // 3: grocery.v1.FoodItem.effective_expiration:type_name -> google.protobuf.Timestamp
+	10, // 4: grocery.v1.CreateFoodRequest.expiration:type_name -> google.protobuf.Timestamp
+	0,  // 5: grocery.v1.CreateFoodRequest.nutrition:type_name -> grocery.v1.Nutrition
+	10, // 6: grocery.v1.FoodEvent.time:type_name -> google.protobuf.Timestamp
+	1,  // 7: grocery.v1.FoodEvent.food:type_name -> grocery.v1.FoodItem
+	2,  // 8: grocery.v1.GroceryService.CreateFood:input_type -> grocery.v1.CreateFoodRequest
+	4,  // 9: grocery.v1.GroceryService.GetFood:input_type -> grocery.v1.GetFoodRequest
+	5,  // 10: grocery.v1.GroceryService.ListFood:input_type -> grocery.v1.ListFoodRequest
+	6,  // 11: grocery.v1.GroceryService.DeleteFood:input_type -> grocery.v1.DeleteFoodRequest
+	8,  // 12: grocery.v1.GroceryService.WatchFood:input_type -> grocery.v1.WatchFoodRequest
+	3,  // 13: grocery.v1.GroceryService.CreateFood:output_type -> grocery.v1.CreateFoodResponse
+	1,  // 14: grocery.v1.GroceryService.GetFood:output_type -> grocery.v1.FoodItem
+	1,  // 15: grocery.v1.GroceryService.ListFood:output_type -> grocery.v1.FoodItem
+	7,  // 16: grocery.v1.GroceryService.DeleteFood:output_type -> grocery.v1.DeleteFoodResponse
+	9,  // 17: grocery.v1.GroceryService.WatchFood:output_type -> grocery.v1.FoodEvent
+	13, // [13:18] is the sub-list for method output_type
+	8,  // [8:13] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_internal_grocerypb_grocery_proto_init() }
+func file_internal_grocerypb_grocery_proto_init() {
+	if File_internal_grocerypb_grocery_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_grocerypb_grocery_proto_rawDesc), len(file_internal_grocerypb_grocery_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_internal_grocerypb_grocery_proto_goTypes,
+		DependencyIndexes: file_internal_grocerypb_grocery_proto_depIdxs,
+		MessageInfos:      file_internal_grocerypb_grocery_proto_msgTypes,
+	}.Build()
+	File_internal_grocerypb_grocery_proto = out.File
+	file_internal_grocerypb_grocery_proto_goTypes = nil
+	file_internal_grocerypb_grocery_proto_depIdxs = nil
+}
@@ -0,0 +1,301 @@
+// gRPC API over the grocery item store, mirroring the REST FoodItem and
+// Nutrition types.
+//
+// Regenerate the Go code after changing this file with:
+//
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//          --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//          internal/grocerypb/grocery.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             (unknown)
+// source: internal/grocerypb/grocery.proto
+
+package grocerypb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	GroceryService_CreateFood_FullMethodName = "/grocery.v1.GroceryService/CreateFood"
+	GroceryService_GetFood_FullMethodName    = "/grocery.v1.GroceryService/GetFood"
+	GroceryService_ListFood_FullMethodName   = "/grocery.v1.GroceryService/ListFood"
+	GroceryService_DeleteFood_FullMethodName = "/grocery.v1.GroceryService/DeleteFood"
+	GroceryService_WatchFood_FullMethodName  = "/grocery.v1.GroceryService/WatchFood"
+)
+
+// GroceryServiceClient is the client API for GroceryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GroceryServiceClient interface {
+	// CreateFood creates a new food item and returns its ID. Needs basic auth.
+	CreateFood(ctx context.Context, in *CreateFoodRequest, opts ...grpc.CallOption) (*CreateFoodResponse, error)
+	// GetFood returns a single food item.
+	GetFood(ctx context.Context, in *GetFoodRequest, opts ...grpc.CallOption) (*FoodItem, error)
+	// ListFood streams all the food items, optionally only those with the
+	// given ingredient.
+	ListFood(ctx context.Context, in *ListFoodRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FoodItem], error)
+	// DeleteFood deletes a food item. Needs basic auth.
+	DeleteFood(ctx context.Context, in *DeleteFoodRequest, opts ...grpc.CallOption) (*DeleteFoodResponse, error)
+	// WatchFood streams changes to food items as they happen.
+	WatchFood(ctx context.Context, in *WatchFoodRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FoodEvent], error)
+}
+
+type groceryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGroceryServiceClient(cc grpc.ClientConnInterface) GroceryServiceClient {
+	return &groceryServiceClient{cc}
+}
+
+func (c *groceryServiceClient) CreateFood(ctx context.Context, in *CreateFoodRequest, opts ...grpc.CallOption) (*CreateFoodResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateFoodResponse)
+	err := c.cc.Invoke(ctx, GroceryService_CreateFood_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *groceryServiceClient) GetFood(ctx context.Context, in *GetFoodRequest, opts ...grpc.CallOption) (*FoodItem, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FoodItem)
+	err := c.cc.Invoke(ctx, GroceryService_GetFood_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *groceryServiceClient) ListFood(ctx context.Context, in *ListFoodRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FoodItem], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GroceryService_ServiceDesc.Streams[0], GroceryService_ListFood_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListFoodRequest, FoodItem]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GroceryService_ListFoodClient = grpc.ServerStreamingClient[FoodItem]
+
+func (c *groceryServiceClient) DeleteFood(ctx context.Context, in *DeleteFoodRequest, opts ...grpc.CallOption) (*DeleteFoodResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteFoodResponse)
+	err := c.cc.Invoke(ctx, GroceryService_DeleteFood_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *groceryServiceClient) WatchFood(ctx context.Context, in *WatchFoodRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FoodEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GroceryService_ServiceDesc.Streams[1], GroceryService_WatchFood_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchFoodRequest, FoodEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GroceryService_WatchFoodClient = grpc.ServerStreamingClient[FoodEvent]
+
+// GroceryServiceServer is the server API for GroceryService service.
+// All implementations must embed UnimplementedGroceryServiceServer
+// for forward compatibility.
+type GroceryServiceServer interface {
+	// CreateFood creates a new food item and returns its ID. Needs basic auth.
+	CreateFood(context.Context, *CreateFoodRequest) (*CreateFoodResponse, error)
+	// GetFood returns a single food item.
+	GetFood(context.Context, *GetFoodRequest) (*FoodItem, error)
+	// ListFood streams all the food items, optionally only those with the
+	// given ingredient.
+	ListFood(*ListFoodRequest, grpc.ServerStreamingServer[FoodItem]) error
+	// DeleteFood deletes a food item. Needs basic auth.
+	DeleteFood(context.Context, *DeleteFoodRequest) (*DeleteFoodResponse, error)
+	// WatchFood streams changes to food items as they happen.
+	WatchFood(*WatchFoodRequest, grpc.ServerStreamingServer[FoodEvent]) error
+	mustEmbedUnimplementedGroceryServiceServer()
+}
+
+// UnimplementedGroceryServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGroceryServiceServer struct{}
+
+func (UnimplementedGroceryServiceServer) CreateFood(context.Context, *CreateFoodRequest) (*CreateFoodResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateFood not implemented")
+}
+func (UnimplementedGroceryServiceServer) GetFood(context.Context, *GetFoodRequest) (*FoodItem, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetFood not implemented")
+}
+func (UnimplementedGroceryServiceServer) ListFood(*ListFoodRequest, grpc.ServerStreamingServer[FoodItem]) error {
+	return status.Error(codes.Unimplemented, "method ListFood not implemented")
+}
+func (UnimplementedGroceryServiceServer) DeleteFood(context.Context, *DeleteFoodRequest) (*DeleteFoodResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteFood not implemented")
+}
+func (UnimplementedGroceryServiceServer) WatchFood(*WatchFoodRequest, grpc.ServerStreamingServer[FoodEvent]) error {
+	return status.Error(codes.Unimplemented, "method WatchFood not implemented")
+}
+func (UnimplementedGroceryServiceServer) mustEmbedUnimplementedGroceryServiceServer() {}
+func (UnimplementedGroceryServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeGroceryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GroceryServiceServer will
+// result in compilation errors.
+type UnsafeGroceryServiceServer interface {
+	mustEmbedUnimplementedGroceryServiceServer()
+}
+
+func RegisterGroceryServiceServer(s grpc.ServiceRegistrar, srv GroceryServiceServer) {
+	// If the following call panics, it indicates UnimplementedGroceryServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&GroceryService_ServiceDesc, srv)
+}
+
+func _GroceryService_CreateFood_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateFoodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GroceryServiceServer).CreateFood(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GroceryService_CreateFood_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GroceryServiceServer).CreateFood(ctx, req.(*CreateFoodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GroceryService_GetFood_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFoodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GroceryServiceServer).GetFood(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GroceryService_GetFood_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GroceryServiceServer).GetFood(ctx, req.(*GetFoodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GroceryService_ListFood_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListFoodRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GroceryServiceServer).ListFood(m, &grpc.GenericServerStream[ListFoodRequest, FoodItem]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GroceryService_ListFoodServer = grpc.ServerStreamingServer[FoodItem]
+
+func _GroceryService_DeleteFood_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteFoodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GroceryServiceServer).DeleteFood(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GroceryService_DeleteFood_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GroceryServiceServer).DeleteFood(ctx, req.(*DeleteFoodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GroceryService_WatchFood_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchFoodRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GroceryServiceServer).WatchFood(m, &grpc.GenericServerStream[WatchFoodRequest, FoodEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GroceryService_WatchFoodServer = grpc.ServerStreamingServer[FoodEvent]
+
+// GroceryService_ServiceDesc is the grpc.ServiceDesc for GroceryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GroceryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "grocery.v1.GroceryService",
+	HandlerType: (*GroceryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateFood",
+			Handler:    _GroceryService_CreateFood_Handler,
+		},
+		{
+			MethodName: "GetFood",
+			Handler:    _GroceryService_GetFood_Handler,
+		},
+		{
+			MethodName: "DeleteFood",
+			Handler:    _GroceryService_DeleteFood_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListFood",
+			Handler:       _GroceryService_ListFood_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchFood",
+			Handler:       _GroceryService_WatchFood_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "internal/grocerypb/grocery.proto",
+}
@@ -0,0 +1,285 @@
+// gRPC service over the grocery item store.
+//
+// The service shares its store, change feed and user database with the REST
+// API. Mutating RPCs need the same basic auth credentials, passed in the
+// "authorization" metadata as "Basic <base64 user:password>". Calls are rate
+// limited like REST requests: mutating RPCs by the write policy, the others
+// by the read policy.
+
+package grpcserver
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/diorchen/rest-server/internal/authdb"
+	"github.com/diorchen/rest-server/internal/feed"
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	pb "github.com/diorchen/rest-server/internal/grocerypb"
+	"github.com/diorchen/rest-server/internal/middleware"
+	"github.com/diorchen/rest-server/internal/ratelimit"
+	"github.com/diorchen/rest-server/internal/validate"
+)
+
+// authMethods lists the RPCs that need an authenticated user.
+var authMethods = map[string]bool{
+	pb.GroceryService_CreateFood_FullMethodName: true,
+	pb.GroceryService_DeleteFood_FullMethodName: true,
+}
+
+type server struct {
+	pb.UnimplementedGroceryServiceServer
+
+	store *groceryItemStore.GroceryItemStore
+	hub   *feed.Hub
+}
+
+// New creates a gRPC server exposing GroceryService backed by store, with
+// WatchFood following the events of hub. Calls are rate limited by limits,
+// unless it's nil.
+func New(store *groceryItemStore.GroceryItemStore, hub *feed.Hub, limits *ratelimit.RateLimiter) *grpc.Server {
+	g := gate{limits: limits}
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(g.unary),
+		grpc.StreamInterceptor(g.stream),
+	)
+	pb.RegisterGroceryServiceServer(s, &server{store: store, hub: hub})
+	return s
+}
+
+// gate rate limits calls, and authenticates those of authMethods.
+type gate struct {
+	limits *ratelimit.RateLimiter
+}
+
+// credentials returns the basic auth credentials in the metadata of ctx.
+func credentials(ctx context.Context) (user, pass string, ok bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, auth := range md.Get("authorization") {
+		const prefix = "Basic "
+		if !strings.HasPrefix(auth, prefix) {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(auth[len(prefix):])
+		if err != nil {
+			continue
+		}
+		if user, pass, ok = strings.Cut(string(decoded), ":"); ok {
+			return user, pass, true
+		}
+	}
+	return "", "", false
+}
+
+// admit takes a token for a call of method, and, if the method needs it,
+// checks its credentials and returns a context carrying the user, like
+// middleware.BasicAuth does. Credentials are verified only once the buckets
+// allow it, as for REST requests.
+func (g gate) admit(ctx context.Context, method string) (context.Context, error) {
+	write := authMethods[method]
+	user, pass, claimed := credentials(ctx)
+	verified, valid := false, false
+	verify := func() bool {
+		verified, valid = true, authdb.VerifyUserPass(user, pass)
+		return valid
+	}
+
+	if limiter := g.limits.Limiter(write); limiter != nil {
+		var c ratelimit.Client
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			c.Addr, _, _ = net.SplitHostPort(p.Addr.String())
+		}
+		if claimed && write {
+			c.User = user
+		}
+		r, key := limiter.Take(c, verify)
+		if !r.Allowed {
+			middleware.Logger(ctx).Warn("rate limited", "key", key, "method", method)
+			return nil, status.Errorf(codes.ResourceExhausted, "too many requests, retry in %d seconds", int(math.Ceil(r.RetryAfter.Seconds())))
+		}
+	}
+
+	if !write {
+		return ctx, nil
+	}
+	if claimed && !verified {
+		verify()
+	}
+	if !valid {
+		return nil, status.Error(codes.Unauthenticated, "Unauthorized")
+	}
+	return context.WithValue(ctx, middleware.UserContextKey, user), nil
+}
+
+func (g gate) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := g.admit(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (g gate) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if _, err := g.admit(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func toProto(food groceryItemStore.FoodItem) *pb.FoodItem {
+	item := &pb.FoodItem{
+		Id:          int64(food.Id),
+		Name:        food.Name,
+		Description: food.Description,
+		Ingredients: food.Ingredients,
+		Expiration:  timestamp(food.Expiration),
+		Nutrition: &pb.Nutrition{
+			Calories:      int32(food.Nutrition.Calories),
+			Protein:       food.Nutrition.Protein,
+			Carbohydrates: food.Nutrition.Carbohydrates,
+			Fat:           food.Nutrition.Fat,
+			Fiber:         food.Nutrition.Fiber,
+		},
+		Location:            int64(food.Location),
+		Category:            food.Category,
+		EffectiveExpiration: timestamp(food.EffectiveExpiration),
+	}
+	if food.Opened != nil {
+		item.Opened = timestamp(*food.Opened)
+	}
+	return item
+}
+
+// storeStatus converts an error of the store to a status with code, unless
+// the store gave up because the call's deadline passed or it was canceled.
+func storeStatus(code codes.Code, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(code, err.Error())
+}
+
+func (s *server) CreateFood(ctx context.Context, req *pb.CreateFoodRequest) (*pb.CreateFoodResponse, error) {
+	food := groceryItemStore.FoodItem{
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		Ingredients: req.GetIngredients(),
+		Location:    int(req.GetLocation()),
+		Category:    req.GetCategory(),
+	}
+	if req.Expiration != nil {
+		food.Expiration = req.Expiration.AsTime()
+	}
+	if n := req.GetNutrition(); n != nil {
+		food.Nutrition = groceryItemStore.Nutrition{
+			Calories:      int(n.Calories),
+			Protein:       n.Protein,
+			Carbohydrates: n.Carbohydrates,
+			Fat:           n.Fat,
+			Fiber:         n.Fiber,
+		}
+	}
+
+	if err := validate.Struct(food); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	id, err := s.store.AddFood(ctx, food)
+	if err != nil {
+		return nil, storeStatus(codes.InvalidArgument, err)
+	}
+	return &pb.CreateFoodResponse{Id: int64(id)}, nil
+}
+
+func (s *server) GetFood(ctx context.Context, req *pb.GetFoodRequest) (*pb.FoodItem, error) {
+	food, err := s.store.GetFood(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, storeStatus(codes.NotFound, err)
+	}
+	return toProto(food), nil
+}
+
+func (s *server) ListFood(req *pb.ListFoodRequest, stream pb.GroceryService_ListFoodServer) error {
+	var foods []groceryItemStore.FoodItem
+	var err error
+	if ing := req.GetIngredient(); ing != "" {
+		foods, err = s.store.GetFoodByIng(stream.Context(), ing)
+	} else {
+		foods, err = s.store.GetAllFood(stream.Context())
+	}
+	if err != nil {
+		return storeStatus(codes.Internal, err)
+	}
+
+	for _, food := range foods {
+		if err := stream.Send(toProto(food)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *server) DeleteFood(ctx context.Context, req *pb.DeleteFoodRequest) (*pb.DeleteFoodResponse, error) {
+	if err := s.store.DeleteFood(ctx, int(req.GetId())); err != nil {
+		return nil, storeStatus(codes.NotFound, err)
+	}
+	return &pb.DeleteFoodResponse{}, nil
+}
+
+func (s *server) WatchFood(req *pb.WatchFoodRequest, stream pb.GroceryService_WatchFoodServer) error {
+	sub, replay, complete := s.hub.Subscribe(int(req.GetLastEventId()), 64)
+	defer sub.Close()
+
+	if !complete {
+		return status.Errorf(codes.OutOfRange, "event id=%d is no longer buffered; list the food again and watch from 0", req.GetLastEventId())
+	}
+
+	send := func(ev groceryItemStore.Event) error {
+		if ev.Food == nil {
+			return nil
+		}
+		return stream.Send(&pb.FoodEvent{
+			Id:   int64(ev.Id),
+			Type: ev.Type,
+			Time: timestamp(ev.Time),
+			Food: toProto(*ev.Food),
+		})
+	}
+
+	for _, ev := range replay {
+		if err := send(ev); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev, ok := <-sub.C:
+			if !ok {
+				return status.Error(codes.Unavailable, "watcher fell behind or server is shutting down; resume from the last event id")
+			}
+			if err := send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
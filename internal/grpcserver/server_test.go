@@ -0,0 +1,185 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/diorchen/rest-server/internal/config"
+	"github.com/diorchen/rest-server/internal/feed"
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	pb "github.com/diorchen/rest-server/internal/grocerypb"
+	"github.com/diorchen/rest-server/internal/ratelimit"
+)
+
+func newTestClient(t *testing.T) (pb.GroceryServiceClient, *groceryItemStore.GroceryItemStore) {
+	return newLimitedClient(t, nil)
+}
+
+// newLimitedClient is like newTestClient, with calls rate limited by limits.
+func newLimitedClient(t *testing.T, limits *ratelimit.RateLimiter) (pb.GroceryServiceClient, *groceryItemStore.GroceryItemStore) {
+	gis := groceryItemStore.New()
+	hub := feed.NewHub(16)
+	gis.Subscribe(hub.Publish)
+
+	lis := bufconn.Listen(1 << 20)
+	s := New(gis, hub, limits)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewGroceryServiceClient(conn), gis
+}
+
+func withAuth(ctx context.Context, user, pass string) context.Context {
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))
+	return metadata.AppendToOutgoingContext(ctx, "authorization", auth)
+}
+
+func TestCreateNeedsAuth(t *testing.T) {
+	client, _ := newTestClient(t)
+	_, err := client.CreateFood(context.Background(), &pb.CreateFoodRequest{Name: "Milk"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("got %v, want Unauthenticated", err)
+	}
+	_, err = client.CreateFood(withAuth(context.Background(), "joe", "wrong"), &pb.CreateFoodRequest{Name: "Milk"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("got %v with bad password, want Unauthenticated", err)
+	}
+}
+
+func TestCreateFoodValidates(t *testing.T) {
+	client, gis := newTestClient(t)
+	ctx := withAuth(context.Background(), "joe", "1234")
+
+	_, err := client.CreateFood(ctx, &pb.CreateFoodRequest{Name: "", Nutrition: &pb.Nutrition{Protein: -1}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("got %v, want InvalidArgument", err)
+	}
+	for _, want := range []string{"name is required", "expiration is required", "nutrition.protein must be at least 0"} {
+		if !strings.Contains(status.Convert(err).Message(), want) {
+			t.Errorf("got message %q, want %q", status.Convert(err).Message(), want)
+		}
+	}
+	if food, _ := gis.GetAllFood(context.Background()); len(food) != 0 {
+		t.Errorf("got %v, want the invalid food not stored", food)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	client, gis := newLimitedClient(t, ratelimit.New(config.RateLimit{
+		Read:  config.Policy{Requests: 2, Period: config.Duration(time.Minute)},
+		Write: config.Policy{Requests: 1, Period: config.Duration(time.Minute)},
+	}))
+	id := gis.CreateFood(context.Background(), "Milk", "", nil, time.Time{}, groceryItemStore.Nutrition{})
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetFood(context.Background(), &pb.GetFoodRequest{Id: int64(id)}); err != nil {
+			t.Fatalf("read %d: %v", i, err)
+		}
+	}
+	if _, err := client.GetFood(context.Background(), &pb.GetFoodRequest{Id: int64(id)}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("read over the limit: got %v, want ResourceExhausted", err)
+	}
+
+	// A failed attempt uses up the address's writes, so the next attempt isn't
+	// even verified.
+	ctx := withAuth(context.Background(), "joe", "wrong")
+	if _, err := client.DeleteFood(ctx, &pb.DeleteFoodRequest{Id: int64(id)}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("write with a bad password: got %v, want Unauthenticated", err)
+	}
+	ctx = withAuth(context.Background(), "mary", "wrong")
+	if _, err := client.DeleteFood(ctx, &pb.DeleteFoodRequest{Id: int64(id)}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("write after a failed attempt: got %v, want ResourceExhausted", err)
+	}
+}
+
+func TestGetListDelete(t *testing.T) {
+	client, gis := newTestClient(t)
+	ctx := context.Background()
+	id := gis.CreateFood(context.Background(), "Milk", "Whole", []string{"Milk"}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), groceryItemStore.Nutrition{Calories: 42})
+	gis.CreateFood(context.Background(), "Bread", "", []string{"Flour"}, time.Time{}, groceryItemStore.Nutrition{})
+
+	food, err := client.GetFood(ctx, &pb.GetFoodRequest{Id: int64(id)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if food.Name != "Milk" || food.Nutrition.Calories != 42 || !food.Expiration.AsTime().Equal(time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %v", food)
+	}
+	if _, err := client.GetFood(ctx, &pb.GetFoodRequest{Id: 100}); status.Code(err) != codes.NotFound {
+		t.Errorf("got %v for missing food, want NotFound", err)
+	}
+
+	stream, err := client.ListFood(ctx, &pb.ListFoodRequest{Ingredient: "Flour"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for {
+		food, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, food.Name)
+	}
+	if len(names) != 1 || names[0] != "Bread" {
+		t.Errorf("got %v, want [Bread]", names)
+	}
+
+	if _, err := client.DeleteFood(ctx, &pb.DeleteFoodRequest{Id: int64(id)}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("got %v deleting without auth, want Unauthenticated", err)
+	}
+}
+
+func TestWatchFood(t *testing.T) {
+	client, gis := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gis.CreateFood(context.Background(), "Milk", "", nil, time.Time{}, groceryItemStore.Nutrition{})
+
+	// Watching from 0 only streams new events, not the milk created above.
+	stream, err := client.WatchFood(ctx, &pb.WatchFoodRequest{LastEventId: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	gis.CreateFood(context.Background(), "Bread", "", nil, time.Time{}, groceryItemStore.Nutrition{})
+
+	ev, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Type != groceryItemStore.FoodCreated || ev.Food.Name != "Bread" || ev.Id != 2 {
+		t.Errorf("got %v, want bread created as event 2", ev)
+	}
+
+	replayed, err := client.WatchFood(ctx, &pb.WatchFoodRequest{LastEventId: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev, err := replayed.Recv(); err != nil || ev.Id != 2 {
+		t.Errorf("got %v, %v resuming after event 1, want event 2", ev, err)
+	}
+}
@@ -0,0 +1,134 @@
+// Prometheus metrics of the HTTP server and the store.
+//
+// Requests are labelled with the path template of the route they matched, not
+// their path, so that IDs in paths don't create a time series each.
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/middleware"
+)
+
+// unmatchedRoute is the route label of requests that matched no route.
+const unmatchedRoute = "none"
+
+// Metrics collects the metrics of one server.
+type Metrics struct {
+	registry     *prometheus.Registry
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	inFlight     prometheus.Gauge
+	authFailures *prometheus.CounterVec
+}
+
+// New creates the metrics of a server serving store, including the Go runtime
+// and process metrics.
+func New(store *groceryItemStore.GroceryItemStore) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests handled, by method, route and status code.",
+		}, []string{"method", "route", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Latency of HTTP requests, by method and route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests being handled, including open event streams and WebSockets.",
+		}),
+		authFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_auth_failures_total",
+			Help: "Requests rejected for missing or invalid basic auth credentials, by route.",
+		}, []string{"route"}),
+	}
+	m.registry.MustRegister(
+		m.requests, m.duration, m.inFlight, m.authFailures,
+		newStoreCollector(store),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware instruments every request passed to next. It must run inside
+// middleware.Logging, which records the matched route.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		sw := middleware.NewStatusWriter(w)
+		next.ServeHTTP(sw, req)
+
+		route := middleware.RequestRoute(req.Context())
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := sw.Status()
+		m.requests.WithLabelValues(req.Method, route, strconv.Itoa(status)).Inc()
+		m.duration.WithLabelValues(req.Method, route).Observe(time.Since(start).Seconds())
+		if status == http.StatusUnauthorized {
+			m.authFailures.WithLabelValues(route).Inc()
+		}
+	})
+}
+
+// storeCollector reads the size and lock contention of a store on every
+// scrape.
+type storeCollector struct {
+	store *groceryItemStore.GroceryItemStore
+
+	food, expired, locations, rules *prometheus.Desc
+	lockAcquisitions, lockWait      *prometheus.Desc
+}
+
+func newStoreCollector(store *groceryItemStore.GroceryItemStore) *storeCollector {
+	return &storeCollector{
+		store:            store,
+		food:             prometheus.NewDesc("grocery_food_items", "Food items in the store.", nil, nil),
+		expired:          prometheus.NewDesc("grocery_food_items_expired", "Food items past their effective expiration.", nil, nil),
+		locations:        prometheus.NewDesc("grocery_locations", "Storage locations in the store.", nil, nil),
+		rules:            prometheus.NewDesc("grocery_shelf_life_rules", "Shelf-life rules in the store.", nil, nil),
+		lockAcquisitions: prometheus.NewDesc("grocery_store_lock_acquisitions_total", "Times the store's lock was taken.", nil, nil),
+		lockWait:         prometheus.NewDesc("grocery_store_lock_wait_seconds_total", "Time spent waiting for the store's lock.", nil, nil),
+	}
+}
+
+func (c *storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.food
+	ch <- c.expired
+	ch <- c.locations
+	ch <- c.rules
+	ch <- c.lockAcquisitions
+	ch <- c.lockWait
+}
+
+func (c *storeCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.store.Stats()
+	ch <- prometheus.MustNewConstMetric(c.food, prometheus.GaugeValue, float64(stats.Food))
+	ch <- prometheus.MustNewConstMetric(c.expired, prometheus.GaugeValue, float64(stats.Expired))
+	ch <- prometheus.MustNewConstMetric(c.locations, prometheus.GaugeValue, float64(stats.Locations))
+	ch <- prometheus.MustNewConstMetric(c.rules, prometheus.GaugeValue, float64(stats.Rules))
+
+	lock := c.store.LockStats()
+	ch <- prometheus.MustNewConstMetric(c.lockAcquisitions, prometheus.CounterValue, float64(lock.Acquisitions))
+	ch <- prometheus.MustNewConstMetric(c.lockWait, prometheus.CounterValue, lock.Wait.Seconds())
+}
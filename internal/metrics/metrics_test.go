@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/middleware"
+)
+
+func TestMetrics(t *testing.T) {
+	store := groceryItemStore.New()
+	store.CreateFood(context.Background(), "Milk", "", []string{"Milk"}, time.Now().Add(-time.Hour), groceryItemStore.Nutrition{})
+	m := New(store)
+
+	router := mux.NewRouter()
+	router.Use(middleware.Route)
+	router.HandleFunc("/food/{id:[0-9]+}/", func(w http.ResponseWriter, req *http.Request) {})
+	router.Handle("/secret/", middleware.BasicAuth(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})))
+	h := middleware.Logging(slog.New(slog.NewTextHandler(io.Discard, nil)))(m.Middleware(router))
+
+	for _, path := range []string{"/food/1/", "/food/2/", "/secret/", "/nowhere/"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	body := rr.Body.String()
+	for _, want := range []string{
+		`http_requests_total{code="200",method="GET",route="/food/{id:[0-9]+}/"} 2`,
+		`http_requests_total{code="404",method="GET",route="none"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/food/{id:[0-9]+}/"} 2`,
+		`http_auth_failures_total{route="/secret/"} 1`,
+		"http_requests_in_flight 0",
+		"grocery_food_items 1",
+		"grocery_food_items_expired 1",
+		"grocery_store_lock_acquisitions_total",
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %q", want)
+		}
+	}
+}
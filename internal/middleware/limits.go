@@ -0,0 +1,91 @@
+// Limits on the size and duration of requests.
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// maxBytes is a handler with a body limit of its own; see MaxBytes.
+type maxBytes struct {
+	limit int64
+	next  http.Handler
+}
+
+func (h maxBytes) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	req.Body = http.MaxBytesReader(w, req.Body, h.limit)
+	h.next.ServeHTTP(w, req)
+}
+
+// MaxBytes limits the request bodies of next to limit bytes, instead of the
+// limit of BodyLimit. Reading past the limit fails with an
+// *http.MaxBytesError. Routes taking large bodies, like imports, register
+// their handler wrapped in it.
+func MaxBytes(limit int64, next http.Handler) http.Handler {
+	return maxBytes{limit: limit, next: next}
+}
+
+// BodyLimit returns router middleware limiting request bodies to limit bytes,
+// unless the matched route's handler sets its own limit with MaxBytes.
+func BodyLimit(limit int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if route := mux.CurrentRoute(req); route != nil {
+				if _, ok := route.GetHandler().(maxBytes); ok {
+					next.ServeHTTP(w, req)
+					return
+				}
+			}
+			req.Body = http.MaxBytesReader(w, req.Body, limit)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// streaming is a handler whose responses last as long as the client wants;
+// see Streaming.
+type streaming struct {
+	next http.Handler
+}
+
+func (h streaming) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	clearDeadlines(w)
+	h.next.ServeHTTP(w, req)
+}
+
+// Streaming exempts the requests handled by next, such as event streams and
+// WebSockets, from the server's read and write timeouts and from Timeout.
+func Streaming(next http.Handler) http.Handler {
+	return streaming{next: next}
+}
+
+// clearDeadlines lifts the server's read and write timeouts for the rest of
+// the request. Writers that can't, like test recorders, have none anyway.
+func clearDeadlines(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+}
+
+// Timeout returns router middleware giving each request d to finish: its
+// context is done after d, so store operations waiting for it give up.
+// Routes registered with Streaming are exempt.
+func Timeout(d time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if route := mux.CurrentRoute(req); route != nil {
+				if _, ok := route.GetHandler().(streaming); ok {
+					next.ServeHTTP(w, req)
+					return
+				}
+			}
+			ctx, cancel := context.WithTimeout(req.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
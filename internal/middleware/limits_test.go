@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestBodyLimit(t *testing.T) {
+	read := func(w http.ResponseWriter, req *http.Request) {
+		if _, err := io.ReadAll(req.Body); err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		}
+	}
+	router := mux.NewRouter()
+	router.Use(BodyLimit(4))
+	router.HandleFunc("/small", read)
+	router.Handle("/large", MaxBytes(16, http.HandlerFunc(read)))
+
+	tests := []struct {
+		path string
+		body string
+		want int
+	}{
+		{"/small", "1234", http.StatusOK},
+		{"/small", "12345", http.StatusRequestEntityTooLarge},
+		{"/large", "12345", http.StatusOK},
+		{"/large", strings.Repeat("1", 17), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body)))
+		if rr.Code != tt.want {
+			t.Errorf("%d bytes to %s: got status %d, want %d", len(tt.body), tt.path, rr.Code, tt.want)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	deadline := func(w http.ResponseWriter, req *http.Request) {
+		if _, ok := req.Context().Deadline(); ok {
+			w.WriteHeader(http.StatusGatewayTimeout)
+		}
+	}
+	router := mux.NewRouter()
+	router.Use(Timeout(time.Second))
+	router.HandleFunc("/food/", deadline)
+	router.Handle("/events", Streaming(http.HandlerFunc(deadline)))
+
+	tests := []struct {
+		path     string
+		accept   string
+		deadline bool
+	}{
+		{"/food/", "", true},
+		{"/food/", "text/event-stream", true},
+		{"/events", "", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Header.Set("Accept", tt.accept)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if got := rr.Code == http.StatusGatewayTimeout; got != tt.deadline {
+			t.Errorf("%s accepting %q: got deadline %v, want %v", tt.path, tt.accept, got, tt.deadline)
+		}
+	}
+}
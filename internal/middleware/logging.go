@@ -0,0 +1,255 @@
+// Structured request logging with log/slog.
+
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the ID of a request. A valid ID sent by the client is
+// kept, so requests can be followed across services; otherwise one is
+// generated. Either way it's sent back in the response.
+const RequestIDHeader = "X-Request-ID"
+
+// NewLogger creates a logger writing to w at the given level ("debug", "info",
+// "warn" or "error") in the given format ("json" or "text").
+func NewLogger(w io.Writer, level string, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q, expect debug, info, warn or error", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q, expect json or text", format)
+}
+
+// requestLog collects what the handlers of a request learn about it, such as
+// the route and the user, for the line Logging writes once it's done.
+type requestLog struct {
+	sync.Mutex
+	logger *slog.Logger
+	route  string
+	user   string
+	quiet  bool // set by NoAccessLog
+}
+
+type requestLogKey struct{}
+
+func getRequestLog(ctx context.Context) *requestLog {
+	rl, _ := ctx.Value(requestLogKey{}).(*requestLog)
+	return rl
+}
+
+// Logger returns the logger of the request ctx belongs to, which adds the
+// request ID to every record, or the default logger outside of requests.
+func Logger(ctx context.Context) *slog.Logger {
+	if rl := getRequestLog(ctx); rl != nil {
+		return rl.logger
+	}
+	return slog.Default()
+}
+
+// setUser records the authenticated user of the request ctx belongs to.
+func setUser(ctx context.Context, user string) {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.Lock()
+		rl.user = user
+		rl.Unlock()
+	}
+}
+
+// Logging logs a line for every request once it's handled, with its request ID,
+// method, path, route template, user, status, size and latency. Server errors
+// are logged at error level, client errors at warn level. Requests traced by
+// an outer middleware also log their trace and span IDs.
+func Logging(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			id := req.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			reqLogger := logger.With("request_id", id)
+			if sc := trace.SpanContextFromContext(req.Context()); sc.IsValid() {
+				reqLogger = reqLogger.With("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+			}
+			rl := &requestLog{logger: reqLogger}
+			ctx := context.WithValue(req.Context(), requestLogKey{}, rl)
+			sw := NewStatusWriter(w)
+			next.ServeHTTP(sw, req.WithContext(ctx))
+
+			level := slog.LevelInfo
+			switch {
+			case sw.Status() >= 500:
+				level = slog.LevelError
+			case sw.Status() >= 400:
+				level = slog.LevelWarn
+			}
+			rl.Lock()
+			route, user, quiet := rl.route, rl.user, rl.quiet
+			rl.Unlock()
+			if quiet {
+				return
+			}
+			rl.logger.LogAttrs(ctx, level, "request",
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.String("route", route),
+				slog.String("user", user),
+				slog.Int("status", sw.Status()),
+				slog.Int64("bytes", sw.Bytes()),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.String("remote", req.RemoteAddr),
+			)
+		})
+	}
+}
+
+// RequestRoute returns the path template of the route the request ctx belongs
+// to matched, as recorded by Route, or "" if it matched none (yet).
+func RequestRoute(ctx context.Context) string {
+	rl := getRequestLog(ctx)
+	if rl == nil {
+		return ""
+	}
+	rl.Lock()
+	defer rl.Unlock()
+	return rl.route
+}
+
+// Route records the path template of the route a request matched for
+// Logging, and names the request's span after it. It's meant for Router.Use,
+// after the route is known.
+func Route(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if route := mux.CurrentRoute(req); route != nil {
+			if tpl, err := route.GetPathTemplate(); err == nil {
+				if rl := getRequestLog(req.Context()); rl != nil {
+					rl.Lock()
+					rl.route = tpl
+					rl.Unlock()
+				}
+				span := trace.SpanFromContext(req.Context())
+				span.SetName(req.Method + " " + tpl)
+				span.SetAttributes(semconv.HTTPRoute(tpl))
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// NoAccessLog keeps Logging from logging the line of requests handled by next,
+// such as frequent health probes. Records logged by the handlers are kept.
+func NoAccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if rl := getRequestLog(req.Context()); rl != nil {
+			rl.Lock()
+			rl.quiet = true
+			rl.Unlock()
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// validRequestID accepts IDs of up to 128 visible ASCII characters, so that
+// clients can't inject anything into the logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	return strings.IndexFunc(id, func(r rune) bool { return r <= ' ' || r > '~' }) < 0
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// StatusWriter records the status and size of a response. It passes flushes
+// and hijacks through, for event streams and WebSockets.
+type StatusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// NewStatusWriter wraps w to record the status and size of the response.
+func NewStatusWriter(w http.ResponseWriter) *StatusWriter {
+	return &StatusWriter{ResponseWriter: w}
+}
+
+func (w *StatusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *StatusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Status returns the status of the response; handlers that wrote nothing
+// responded 200.
+func (w *StatusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Bytes returns the size of the response body written so far.
+func (w *StatusWriter) Bytes() int64 {
+	return w.bytes
+}
+
+func (w *StatusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *StatusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T doesn't support hijacking", w.ResponseWriter)
+	}
+	w.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *StatusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
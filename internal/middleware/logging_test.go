@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestNewLogger(t *testing.T) {
+	if _, err := NewLogger(&bytes.Buffer{}, "verbose", "json"); err == nil {
+		t.Error("unknown level, got no error")
+	}
+	if _, err := NewLogger(&bytes.Buffer{}, "info", "xml"); err == nil {
+		t.Error("unknown format, got no error")
+	}
+
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, "warn", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown")
+	if got := buf.String(); strings.Contains(got, "hidden") || !strings.Contains(got, "msg=shown") {
+		t.Errorf("got %q, want only the warning as text", got)
+	}
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := NewLogger(&buf, "debug", "json")
+
+	router := mux.NewRouter()
+	router.Use(Route)
+	router.HandleFunc("/food/{id:[0-9]+}/", func(w http.ResponseWriter, req *http.Request) {
+		setUser(req.Context(), "joe")
+		Logger(req.Context()).Debug("handling")
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := Logging(logger)(router)
+
+	req := httptest.NewRequest("GET", "/food/7/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if id := rr.Header().Get(RequestIDHeader); id != "abc-123" {
+		t.Errorf("got request ID %q, want the client's", id)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got log %q, want a debug line and the request line", buf.String())
+	}
+	var rec map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"level":      "WARN",
+		"msg":        "request",
+		"request_id": "abc-123",
+		"method":     "GET",
+		"route":      "/food/{id:[0-9]+}/",
+		"user":       "joe",
+		"status":     float64(http.StatusTeapot),
+	}
+	for k, v := range want {
+		if rec[k] != v {
+			t.Errorf("got %s=%v, want %v", k, rec[k], v)
+		}
+	}
+	if !strings.Contains(lines[0], `"request_id":"abc-123"`) {
+		t.Errorf("handler log %q lacks the request ID", lines[0])
+	}
+
+	for _, id := range []string{"", "two words", strings.Repeat("x", 200)} {
+		req := httptest.NewRequest("GET", "/food/7/", nil)
+		req.Header.Set(RequestIDHeader, id)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if got := rr.Header().Get(RequestIDHeader); got == id || len(got) != 32 {
+			t.Errorf("sent ID %q, got %q; want a generated one", id, got)
+		}
+	}
+}
+
+func TestNoAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := NewLogger(&buf, "info", "json")
+	h := Logging(logger)(NoAccessLog(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/healthz", nil))
+	if buf.Len() != 0 {
+		t.Errorf("got log %q, want none", buf.String())
+	}
+	if rr.Header().Get(RequestIDHeader) == "" {
+		t.Error("quiet request got no request ID")
+	}
+}
+
+func TestStatusWriterFlush(t *testing.T) {
+	rr := httptest.NewRecorder()
+	var w http.ResponseWriter = NewStatusWriter(rr)
+	f, ok := w.(http.Flusher)
+	if !ok {
+		t.Fatal("StatusWriter isn't a Flusher")
+	}
+	f.Flush()
+	if !rr.Flushed {
+		t.Error("flush didn't reach the underlying writer")
+	}
+}
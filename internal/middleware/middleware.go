@@ -4,22 +4,19 @@ package middleware
 
 import (
 	"context"
-	"log"
 	"net/http"
 	"runtime/debug"
-	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/diorchen/rest-server/internal/authdb"
+	"github.com/diorchen/rest-server/internal/problem"
 )
 
-// Logging information for each request
-func Logging(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { // intercepts incoming request and performs desired operations before calling next handler
-		start := time.Now() // captures start time
-		next.ServeHTTP(w, req) // pass control to next handler in middleware chain, allowing it to continue processing request
-		log.Printf("%s %s %s", req.Method, req.RequestURI, time.Since(start)) // logs HTTP method, requestURI, and elapsed time since start time after next handler concludes
-	})
-}
+var tracer = otel.Tracer("github.com/diorchen/rest-server/internal/middleware")
 
 // PanicRecovery from panics in 'next'
 // returns a StatusInternalError to client
@@ -27,8 +24,8 @@ func PanicRecovery(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {  // intercepts request
 		defer func() {
 			if err := recover(); err != nil { // if recover() called in deferred func, captures value passed to panic(), if no panic, then recover() returns nil
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError) // if panic, generates HTTP error response
-				log.Println(string(debug.Stack())) // logs stack trace of goroutine that panicked (logs details)
+				problem.Write(w, req, problem.New(http.StatusInternalServerError, problem.CodeInternal, "")) // if panic, generates HTTP error response
+				Logger(req.Context()).Error("panic", "error", err, "stack", string(debug.Stack())) // logs stack trace of goroutine that panicked (logs details)
 			}
 		}()
 		next.ServeHTTP(w, req) // calls next handler
@@ -43,14 +40,105 @@ const UserContextKey = "user"
 // BasicAuth is middleware that verifies the request has appropriate basic auth
 // set up with a user:password pair verified by authdb.
 func BasicAuth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		user, pass, ok := req.BasicAuth()
-		if ok && authdb.VerifyUserPass(user, pass) {
-			newctx := context.WithValue(req.Context(), UserContextKey, user)
-			next.ServeHTTP(w, req.WithContext(newctx))
-		} else {
-			w.Header().Set("WWW-Authenticate", `Basic realm="api"`)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	return basicAuth{next: next}
+}
+
+// basicAuth is a handler checking credentials; see BasicAuth and
+// OptionalBasicAuth.
+type basicAuth struct {
+	next     http.Handler
+	optional bool // lets requests without credentials through
+}
+
+func (h basicAuth) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if _, _, ok := req.BasicAuth(); !ok && h.optional {
+		h.next.ServeHTTP(w, req)
+		return
+	}
+	user, ok := verify(req)
+	if ok {
+		setUser(req.Context(), user)
+		newctx := context.WithValue(req.Context(), UserContextKey, user)
+		h.next.ServeHTTP(w, req.WithContext(newctx))
+	} else {
+		Logger(req.Context()).Warn("authentication failed", "user", user)
+		w.Header().Set("WWW-Authenticate", `Basic realm="api"`)
+		problem.Write(w, req, problem.New(http.StatusUnauthorized, problem.CodeUnauthorized, "valid basic auth credentials are required"))
+	}
+}
+
+// Authenticates reports whether the route req matched checks basic auth
+// credentials, with BasicAuth or OptionalBasicAuth. Middleware running before
+// the route's handler, like rate limiting, only verifies credentials where
+// the handler would.
+func Authenticates(req *http.Request) bool {
+	route := mux.CurrentRoute(req)
+	if route == nil {
+		return false
+	}
+	h := route.GetHandler()
+	for {
+		switch wrapper := h.(type) {
+		case basicAuth:
+			return true
+		case maxBytes:
+			h = wrapper.next
+		case streaming:
+			h = wrapper.next
+		default:
+			return false
 		}
-	})
-}
\ No newline at end of file
+	}
+}
+
+// authResult is the outcome of verifying the credentials of a request, kept in
+// its context by Authenticate.
+type authResult struct {
+	user string
+	ok   bool
+}
+
+type authResultKey struct{}
+
+// verify checks the basic auth credentials of req with authdb, unless
+// Authenticate already did. The check, which hashes the password, is traced
+// in a span of its own.
+func verify(req *http.Request) (user string, ok bool) {
+	if r, found := req.Context().Value(authResultKey{}).(authResult); found {
+		return r.user, r.ok
+	}
+	user, pass, ok := req.BasicAuth()
+	if !ok {
+		return user, false
+	}
+	_, span := tracer.Start(req.Context(), "BasicAuth", trace.WithAttributes(attribute.String("enduser.id", user)))
+	defer span.End()
+	ok = authdb.VerifyUserPass(user, pass)
+	span.SetAttributes(attribute.Bool("auth.valid", ok))
+	return user, ok
+}
+
+// Authenticate verifies the basic auth credentials of req, if it has any, for
+// middleware that needs to know the user before BasicAuth runs, like rate
+// limiting. Such middleware checks Authenticates first, so that only routes
+// needing credentials hash passwords. The returned request remembers the
+// outcome, so that BasicAuth doesn't hash the password again. user is empty
+// unless the credentials are valid.
+func Authenticate(req *http.Request) (authenticated *http.Request, user string) {
+	if _, _, ok := req.BasicAuth(); !ok {
+		return req, ""
+	}
+	user, ok := verify(req)
+	req = req.WithContext(context.WithValue(req.Context(), authResultKey{}, authResult{user: user, ok: ok}))
+	if !ok {
+		return req, ""
+	}
+	return req, user
+}
+
+// OptionalBasicAuth is like BasicAuth, but lets requests without credentials
+// through unauthenticated. Handlers check UserContextKey to decide what an
+// anonymous request may do.
+func OptionalBasicAuth(next http.Handler) http.Handler {
+	return basicAuth{next: next, optional: true}
+}
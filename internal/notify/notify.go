@@ -0,0 +1,243 @@
+// Notifications about food that is about to expire.
+//
+// A Notifier periodically scans the store for food expiring within each user's
+// lead times and sends a Notification through every configured Sink. Each food
+// item notifies a user at most once per lead time.
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/middleware"
+)
+
+// Notification tells User that a food item expires soon.
+type Notification struct {
+	User       string    `json:"user"`
+	FoodId     int       `json:"foodId"`
+	Name       string    `json:"name"`
+	Expiration time.Time `json:"expiration"`
+	LeadHours  int       `json:"leadHours"` // the lead time that triggered this notification
+	Time       time.Time `json:"time"`
+}
+
+// Message returns a human readable description of n.
+func (n Notification) Message() string {
+	return fmt.Sprintf("%s (id=%d) expires on %s, within %dh",
+		n.Name, n.FoodId, n.Expiration.Format(time.RFC1123), n.LeadHours)
+}
+
+// Sink delivers notifications somewhere, e.g. to a webhook or a mailbox.
+type Sink interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Preferences are a user's notification settings.
+type Preferences struct {
+	// LeadHours lists how many hours before expiration the user wants to be
+	// notified, e.g. [72, 24] for three days and one day ahead.
+	LeadHours []int `json:"leadHours"`
+
+	// Email is where the SMTP sink sends this user's notifications. Users
+	// without an email don't get mail.
+	Email string `json:"email"`
+}
+
+// Validate checks that all lead times are positive, and that the email, if
+// any, is a plain address that mail can be sent to.
+func (p Preferences) Validate() error {
+	for _, lead := range p.LeadHours {
+		if lead <= 0 {
+			return fmt.Errorf("lead time must be positive, got %dh", lead)
+		}
+	}
+	if p.Email != "" {
+		if addr, err := mail.ParseAddress(p.Email); err != nil || addr.Address != p.Email {
+			return fmt.Errorf("email must be an address like joe@example.com, got %q", p.Email)
+		}
+	}
+	return nil
+}
+
+// sentKey identifies a notification that was already sent. The expiration is
+// part of the key so that an item whose expiration changes notifies again.
+type sentKey struct {
+	user       string
+	foodId     int
+	leadHours  int
+	expiration time.Time
+}
+
+// Notifier scans a GroceryItemStore for expiring food; Notifier methods are
+// safe to call concurrently.
+type Notifier struct {
+	sync.Mutex
+
+	store        *groceryItemStore.GroceryItemStore
+	sinks        []Sink
+	users        []string
+	defaultLeads []int
+	prefs        map[string]Preferences
+	sent         map[sentKey]bool
+
+	now func() time.Time // replaceable in tests
+}
+
+// New creates a Notifier for the given users of store. Users that haven't set
+// their own preferences are notified defaultLeadHours ahead.
+func New(store *groceryItemStore.GroceryItemStore, users []string, defaultLeadHours []int, sinks ...Sink) *Notifier {
+	return &Notifier{
+		store:        store,
+		sinks:        sinks,
+		users:        users,
+		defaultLeads: defaultLeadHours,
+		prefs:        make(map[string]Preferences),
+		sent:         make(map[sentKey]bool),
+		now:          time.Now,
+	}
+}
+
+// GetPreferences returns the preferences of user.
+func (n *Notifier) GetPreferences(user string) Preferences {
+	n.Lock()
+	defer n.Unlock()
+
+	return n.preferences(user)
+}
+
+// SetPreferences replaces the preferences of user.
+func (n *Notifier) SetPreferences(user string, prefs Preferences) error {
+	if err := prefs.Validate(); err != nil {
+		return err
+	}
+
+	n.Lock()
+	defer n.Unlock()
+
+	leads := make([]int, len(prefs.LeadHours))
+	copy(leads, prefs.LeadHours)
+	n.prefs[user] = Preferences{LeadHours: leads, Email: prefs.Email}
+	return nil
+}
+
+// preferences returns the preferences of user, falling back to the defaults.
+// The caller must hold the lock.
+func (n *Notifier) preferences(user string) Preferences {
+	if prefs, ok := n.prefs[user]; ok {
+		return prefs
+	}
+	return Preferences{LeadHours: n.defaultLeads}
+}
+
+// Scan checks the store once and sends a notification for every food item that
+// has crossed one of a user's lead times since the last scan. Only the tightest
+// crossed lead time is notified; larger ones are considered done.
+func (n *Notifier) Scan(ctx context.Context) {
+	n.Lock()
+	now := n.now()
+	maxLead := 0
+	for _, user := range n.users {
+		for _, lead := range n.preferences(user).LeadHours {
+			if lead > maxLead {
+				maxLead = lead
+			}
+		}
+	}
+	foods, err := n.store.GetFoodExpiringBefore(ctx, now.Add(time.Duration(maxLead)*time.Hour))
+	if err != nil {
+		// Scanning was canceled; forgetting what was sent would resend it.
+		n.Unlock()
+		return
+	}
+
+	var pending []Notification
+	seen := make(map[sentKey]bool)
+	for _, user := range n.users {
+		leads := append([]int(nil), n.preferences(user).LeadHours...)
+		sort.Ints(leads)
+
+		for _, food := range foods {
+			fire := true
+			for _, lead := range leads {
+				if food.EffectiveExpiration.Sub(now) > time.Duration(lead)*time.Hour {
+					continue
+				}
+				key := sentKey{user: user, foodId: food.Id, leadHours: lead, expiration: food.EffectiveExpiration}
+				seen[key] = true
+				if fire && !n.sent[key] {
+					pending = append(pending, Notification{
+						User:       user,
+						FoodId:     food.Id,
+						Name:       food.Name,
+						Expiration: food.EffectiveExpiration,
+						LeadHours:  lead,
+						Time:       now,
+					})
+				}
+				fire = false
+				n.sent[key] = true
+			}
+		}
+	}
+
+	// Forget about food that was deleted or whose expiration changed.
+	for key := range n.sent {
+		if !seen[key] {
+			delete(n.sent, key)
+		}
+	}
+	sinks := n.sinks
+	n.Unlock()
+
+	// Sinks may be slow, so deliver without holding the lock.
+	for _, notification := range pending {
+		send(ctx, sinks, notification)
+	}
+}
+
+func send(ctx context.Context, sinks []Sink, notification Notification) {
+	for _, sink := range sinks {
+		if err := sink.Notify(ctx, notification); err != nil {
+			middleware.Logger(ctx).Warn("notifying", "user", notification.User, "food_id", notification.FoodId, "error", err)
+		}
+	}
+}
+
+// Run scans the store every interval until ctx is done.
+func (n *Notifier) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	n.Scan(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n.Scan(ctx)
+		}
+	}
+}
+
+// Email returns the email address of user, or "" if they haven't set one.
+func (n *Notifier) Email(user string) string {
+	n.Lock()
+	defer n.Unlock()
+
+	return n.prefs[user].Email
+}
+
+// AddSink adds a sink to deliver notifications through.
+func (n *Notifier) AddSink(sink Sink) {
+	n.Lock()
+	defer n.Unlock()
+
+	n.sinks = append(n.sinks, sink)
+}
@@ -0,0 +1,179 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+)
+
+func TestScanNotifiesOncePerLeadTime(t *testing.T) {
+	gis := groceryItemStore.New()
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	milk := gis.CreateFood(context.Background(), "Milk", "", nil, now.Add(60*time.Hour), groceryItemStore.Nutrition{})
+	gis.CreateFood(context.Background(), "Rice", "", nil, now.AddDate(1, 0, 0), groceryItemStore.Nutrition{})
+
+	inbox := NewInbox(10)
+	n := New(gis, []string{"joe", "mary"}, []int{72, 24}, inbox)
+	n.now = func() time.Time { return now }
+	if err := n.SetPreferences("mary", Preferences{LeadHours: []int{12}}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	n.Scan(ctx)
+	n.Scan(ctx)
+	if got := inbox.Get("joe"); len(got) != 1 || got[0].FoodId != milk || got[0].LeadHours != 72 {
+		t.Fatalf("joe got %v; want one 72h notification for the milk", got)
+	}
+	if got := inbox.Get("mary"); len(got) != 0 {
+		t.Fatalf("mary got %v; want nothing yet", got)
+	}
+
+	// Two days later the milk crosses joe's 24h and mary's 12h lead times.
+	now = now.Add(50 * time.Hour)
+	n.Scan(ctx)
+	if got := inbox.Get("joe"); len(got) != 2 || got[1].LeadHours != 24 {
+		t.Errorf("joe got %v; want a second, 24h notification", got)
+	}
+	if got := inbox.Get("mary"); len(got) != 1 || got[0].LeadHours != 12 {
+		t.Errorf("mary got %v; want one 12h notification", got)
+	}
+
+	inbox.Clear("joe")
+	if got := inbox.Get("joe"); len(got) != 0 {
+		t.Errorf("got %v after clear; want nothing", got)
+	}
+}
+
+func TestScanNotifiesTightestLeadTimeOnly(t *testing.T) {
+	gis := groceryItemStore.New()
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	gis.CreateFood(context.Background(), "Fish", "", nil, now.Add(time.Hour), groceryItemStore.Nutrition{})
+
+	inbox := NewInbox(10)
+	n := New(gis, []string{"joe"}, []int{72, 24}, inbox)
+	n.now = func() time.Time { return now }
+
+	n.Scan(context.Background())
+	n.Scan(context.Background())
+	if got := inbox.Get("joe"); len(got) != 1 || got[0].LeadHours != 24 {
+		t.Errorf("got %v; want a single 24h notification", got)
+	}
+}
+
+func TestSetPreferencesRejectsBadLeadTime(t *testing.T) {
+	n := New(groceryItemStore.New(), []string{"joe"}, nil)
+	if err := n.SetPreferences("joe", Preferences{LeadHours: []int{0}}); err == nil {
+		t.Fatal("set lead time 0, got no error; want error")
+	}
+}
+
+func TestSetPreferencesRejectsBadEmail(t *testing.T) {
+	n := New(groceryItemStore.New(), []string{"joe"}, nil)
+	for _, email := range []string{"joe", "Joe <joe@example.com>", "joe@example.com\r\nBcc: eve@example.com"} {
+		if err := n.SetPreferences("joe", Preferences{Email: email}); err == nil {
+			t.Errorf("set email %q, got no error; want error", email)
+		}
+	}
+	if err := n.SetPreferences("joe", Preferences{Email: "joe@example.com"}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	got := make(chan Notification, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var n Notification
+		if err := json.NewDecoder(req.Body).Decode(&n); err != nil {
+			t.Error(err)
+		}
+		got <- n
+	}))
+	defer ts.Close()
+
+	sink := NewWebhookSink(ts.URL)
+	if err := sink.Notify(context.Background(), Notification{User: "joe", FoodId: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if n := <-got; n.User != "joe" || n.FoodId != 3 {
+		t.Errorf("webhook received %+v", n)
+	}
+}
+
+func TestSMTPMessage(t *testing.T) {
+	sink := &SMTPSink{From: "fridge@example.com"}
+	n := Notification{User: "joe", FoodId: 3, Name: "Milk\r\nBcc: eve@example.com", LeadHours: 24}
+	msg, err := mail.ReadMessage(bytes.NewReader(sink.message("joe@example.com", n)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Header) != 3 || msg.Header.Get("Bcc") != "" {
+		t.Errorf("got headers %v, want From, To and Subject only", msg.Header)
+	}
+	var dec mime.WordDecoder
+	if subject, err := dec.DecodeHeader(msg.Header.Get("Subject")); err != nil || subject != n.Name+" expires soon" {
+		t.Errorf("got subject %q, %v", subject, err)
+	}
+}
+
+type failingSink struct{}
+
+func (failingSink) Notify(ctx context.Context, n Notification) error {
+	return errors.New("relay down")
+}
+
+func TestSendLogsFailures(t *testing.T) {
+	var log bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&log, nil)))
+
+	send(context.Background(), []Sink{failingSink{}}, Notification{User: "joe", FoodId: 3})
+	var record map[string]any
+	if err := json.Unmarshal(log.Bytes(), &record); err != nil {
+		t.Fatalf("decoding %q: %v", log.String(), err)
+	}
+	if record["user"] != "joe" || record["food_id"] != 3.0 || record["error"] != "relay down" {
+		t.Errorf("got record %v", record)
+	}
+}
+
+func TestSMTPSinkGivesUp(t *testing.T) {
+	// A relay that accepts connections but never answers.
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(io.Discard, conn)
+		}
+	}()
+
+	sink := &SMTPSink{Addr: lis.Addr().String(), From: "fridge@example.com", Email: func(string) string { return "joe@example.com" }}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := sink.Notify(ctx, Notification{User: "joe", Name: "Milk"}); err == nil {
+		t.Error("got no error from a silent relay")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("gave up after %v", d)
+	}
+}
@@ -0,0 +1,184 @@
+// Sinks delivering notifications to a log, a webhook, a mail relay or an in-app
+// inbox.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"sync"
+	"time"
+
+	"github.com/diorchen/rest-server/internal/middleware"
+)
+
+// LogSink writes notifications to the log.
+type LogSink struct{}
+
+func (LogSink) Notify(ctx context.Context, n Notification) error {
+	middleware.Logger(ctx).Info("notification", "user", n.User, "food_id", n.FoodId, "message", n.Message())
+	return nil
+}
+
+// WebhookSink POSTs notifications as JSON to URL.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookSink creates a WebhookSink posting to url.
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (ws *WebhookSink) Notify(ctx context.Context, n Notification) error {
+	js, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ws.URL, bytes.NewReader(js))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ws.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s returned %s", ws.URL, resp.Status)
+	}
+	return nil
+}
+
+// smtpTimeout bounds the delivery of a mail, so a relay that doesn't answer
+// doesn't hold up notifications.
+const smtpTimeout = 30 * time.Second
+
+// SMTPSink mails notifications through an SMTP relay that accepts mail without
+// authentication, typically one listening on localhost.
+type SMTPSink struct {
+	Addr string // host:port of the relay
+	From string
+
+	// Email looks up the address of a user; users without one are skipped.
+	Email func(user string) string
+}
+
+func (ss *SMTPSink) Notify(ctx context.Context, n Notification) error {
+	to := ss.Email(n.User)
+	if to == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+	return ss.send(ctx, to, ss.message(to, n))
+}
+
+// send is smtp.SendMail, giving up when ctx is done.
+func (ss *SMTPSink) send(ctx context.Context, to string, msg []byte) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", ss.Addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(ss.Addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(ss.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message returns the mail notifying to of n. Food names come from users, so
+// the subject is encoded: line breaks in a name could add headers otherwise.
+func (ss *SMTPSink) message(to string, n Notification) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", ss.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Name+" expires soon"))
+	fmt.Fprintf(&msg, "\r\n%s\r\n", n.Message())
+	return msg.Bytes()
+}
+
+// Inbox keeps notifications in memory so users can fetch them through the API;
+// Inbox methods are safe to call concurrently.
+type Inbox struct {
+	sync.Mutex
+
+	max      int // maximum number of notifications kept per user
+	messages map[string][]Notification
+}
+
+// NewInbox creates an Inbox keeping the latest max notifications of each user.
+func NewInbox(max int) *Inbox {
+	return &Inbox{max: max, messages: make(map[string][]Notification)}
+}
+
+func (in *Inbox) Notify(ctx context.Context, n Notification) error {
+	in.Lock()
+	defer in.Unlock()
+
+	msgs := append(in.messages[n.User], n)
+	if len(msgs) > in.max {
+		msgs = msgs[len(msgs)-in.max:]
+	}
+	in.messages[n.User] = msgs
+	return nil
+}
+
+// Get returns the notifications of user, oldest first.
+func (in *Inbox) Get(user string) []Notification {
+	in.Lock()
+	defer in.Unlock()
+
+	msgs := make([]Notification, len(in.messages[user]))
+	copy(msgs, in.messages[user])
+	return msgs
+}
+
+// Clear deletes all the notifications of user.
+func (in *Inbox) Clear(user string) {
+	in.Lock()
+	defer in.Unlock()
+
+	delete(in.messages, user)
+}
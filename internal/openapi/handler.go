@@ -0,0 +1,58 @@
+// HTTP handlers serving the generated document and the docs UI.
+
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/diorchen/rest-server/internal/problem"
+)
+
+//go:embed docs.html
+var docsPage string
+
+// Handler serves the OpenAPI document of Router as JSON. Call Generate once
+// every route is registered, so that stale Operations fail at startup rather
+// than on the first request.
+type Handler struct {
+	Router     *mux.Router
+	Info       Info
+	Operations Operations
+
+	js []byte
+}
+
+// Generate generates the document served by h.
+func (h *Handler) Generate() error {
+	doc, err := Generate(h.Router, h.Info, h.Operations)
+	if err != nil {
+		return err
+	}
+	h.js, err = json.MarshalIndent(doc, "", "  ")
+	return err
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if h.js == nil {
+		problem.Write(w, req, problem.New(http.StatusInternalServerError, problem.CodeInternal, "the OpenAPI document wasn't generated"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(h.js)
+}
+
+// DocsHandler serves a page rendering the OpenAPI document at specURL.
+func DocsHandler(specURL string) http.Handler {
+	// json.Marshal escapes <, > and &, so the URL is safe inside the script.
+	quoted, _ := json.Marshal(specURL)
+	page := strings.Replace(docsPage, "{{SPEC_URL}}", string(quoted), 1)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	})
+}
@@ -0,0 +1,269 @@
+// OpenAPI 3 document generated from the routes of a gorilla/mux router.
+//
+// Every route registered on the router is described; Operations add a summary,
+// whether basic auth is needed and the Go types of the JSON request and
+// response bodies, from which the schemas are derived.
+
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/diorchen/rest-server/internal/problem"
+)
+
+// Version is the OpenAPI version of generated documents.
+const Version = "3.0.3"
+
+// Info is the metadata of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Param is a query or header parameter of an operation.
+type Param struct {
+	Name        string
+	In          string // "query" or "header"
+	Description string
+}
+
+// Operation describes a route for one method.
+type Operation struct {
+	Summary    string
+	Auth       bool        // needs basic auth credentials
+	Deprecated bool        // the route is going away; clients should move off it
+	Params     []Param     // query and header parameters; path parameters come from the route
+	Request    interface{} // value of the type of the JSON request body, or nil
+	Response   interface{} // value of the type of the JSON response body, or nil
+
+	// ContentType is the media type of the response when it isn't JSON, such
+	// as text/event-stream.
+	ContentType string
+
+	// MediaTypes are the media types the Request and Response bodies can be
+	// encoded in, with the same schema; the default is application/json.
+	MediaTypes []string
+}
+
+// content describes a body of type t in each of the media types of o.
+func (o Operation) content(t reflect.Type, s schemas) map[string]mediaType {
+	types := o.MediaTypes
+	if len(types) == 0 {
+		types = []string{"application/json"}
+	}
+	content := map[string]mediaType{}
+	for _, mt := range types {
+		content[mt] = mediaType{Schema: s.of(t)}
+	}
+	return content
+}
+
+// Operations maps "METHOD /path/" to the description of the route, with path
+// variables written without their patterns, as in "GET /food/{id}/".
+type Operations map[string]Operation
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components components                       `json:"components"`
+}
+
+type components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+type operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+// pathVar is a variable in a route's path template, such as {id:[0-9]+}.
+type pathVar struct {
+	name    string
+	pattern string
+}
+
+// splitTemplate strips the patterns from the variables of a mux path template,
+// returning the OpenAPI path and its variables.
+func splitTemplate(tpl string) (string, []pathVar) {
+	var path strings.Builder
+	var vars []pathVar
+	for {
+		start := strings.IndexByte(tpl, '{')
+		if start < 0 {
+			path.WriteString(tpl)
+			return path.String(), vars
+		}
+		// Patterns may contain braces themselves, as in {id:[0-9]{4}}.
+		depth, end := 0, -1
+		for i := start; i < len(tpl) && end < 0; i++ {
+			switch tpl[i] {
+			case '{':
+				depth++
+			case '}':
+				if depth--; depth == 0 {
+					end = i
+				}
+			}
+		}
+		if end < 0 {
+			path.WriteString(tpl)
+			return path.String(), vars
+		}
+
+		name, pattern, _ := strings.Cut(tpl[start+1:end], ":")
+		vars = append(vars, pathVar{name: name, pattern: pattern})
+		path.WriteString(tpl[:start] + "{" + name + "}")
+		tpl = tpl[end+1:]
+	}
+}
+
+func (v pathVar) schema() *Schema {
+	if v.pattern == "[0-9]+" {
+		return &Schema{Type: "integer"}
+	}
+	return &Schema{Type: "string", Pattern: v.pattern}
+}
+
+// problemResponse is an error response with a problem+json body.
+func problemResponse(description string, s schemas) response {
+	return response{
+		Description: description,
+		Content:     map[string]mediaType{problem.ContentType: {Schema: s.of(reflect.TypeOf(problem.Problem{}))}},
+	}
+}
+
+// Generate describes every route of router that has a path template and
+// methods. Routes without an entry in ops are still listed, without schemas.
+// It's an error for ops to describe a route that isn't registered, since that
+// means the descriptions went stale.
+func Generate(router *mux.Router, info Info, ops Operations) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]map[string]*operation{},
+		Components: components{
+			SecuritySchemes: map[string]securityScheme{"basicAuth": {Type: "http", Scheme: "basic"}},
+		},
+	}
+	schemas := schemas{}
+	used := map[string]bool{}
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		path, vars := splitTemplate(tpl)
+
+		for _, method := range methods {
+			key := method + " " + path
+			desc, ok := ops[key]
+			used[key] = ok
+
+			op := &operation{Summary: desc.Summary, Deprecated: desc.Deprecated, Responses: map[string]response{}}
+			for _, v := range vars {
+				op.Parameters = append(op.Parameters, parameter{Name: v.name, In: "path", Required: true, Schema: v.schema()})
+			}
+			for _, p := range desc.Params {
+				op.Parameters = append(op.Parameters, parameter{Name: p.Name, In: p.In, Description: p.Description, Schema: &Schema{Type: "string"}})
+			}
+
+			if desc.Request != nil {
+				op.RequestBody = &requestBody{
+					Required: true,
+					Content:  desc.content(reflect.TypeOf(desc.Request), schemas),
+				}
+				op.Responses["400"] = problemResponse("Invalid request body", schemas)
+			}
+
+			ok200 := response{Description: http.StatusText(http.StatusOK)}
+			switch {
+			case desc.ContentType != "":
+				ok200.Content = map[string]mediaType{desc.ContentType: {Schema: &Schema{Type: "string"}}}
+			case desc.Response != nil:
+				ok200.Content = desc.content(reflect.TypeOf(desc.Response), schemas)
+			}
+			op.Responses["200"] = ok200
+
+			if len(vars) > 0 {
+				op.Responses["404"] = problemResponse(http.StatusText(http.StatusNotFound), schemas)
+			}
+			if desc.Auth {
+				op.Security = []map[string][]string{{"basicAuth": {}}}
+				op.Responses["401"] = problemResponse(http.StatusText(http.StatusUnauthorized), schemas)
+			}
+
+			if doc.Paths[path] == nil {
+				doc.Paths[path] = map[string]*operation{}
+			}
+			doc.Paths[path][strings.ToLower(method)] = op
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []string
+	for key := range ops {
+		if !used[key] {
+			stale = append(stale, key)
+		}
+	}
+	if len(stale) > 0 {
+		sort.Strings(stale)
+		return nil, fmt.Errorf("operations describe unregistered routes: %s", strings.Join(stale, ", "))
+	}
+
+	if len(schemas) > 0 {
+		doc.Components.Schemas = schemas
+	}
+	return doc, nil
+}
@@ -0,0 +1,146 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type nested struct {
+	Calories int `json:"calories"`
+}
+
+type item struct {
+	Id       int               `json:"id"`
+	Name     string            `json:"name,omitempty"`
+	Tags     []string          `json:"tags"`
+	Expires  time.Time         `json:"expires"`
+	Opened   *time.Time        `json:"opened"`
+	Nested   nested            `json:"nested"`
+	Extra    map[string]string `json:"extra"`
+	Secret   string            `json:"-"`
+	NoTag    bool
+	internal int
+}
+
+func TestSchema(t *testing.T) {
+	s := schemas{}
+	ref := s.of(reflect.TypeOf([]item{}))
+	if ref.Type != "array" || ref.Items.Ref != "#/components/schemas/item" {
+		t.Fatalf("got %+v, want array of item references", ref)
+	}
+
+	props := s["item"].Properties
+	want := map[string]string{
+		"id": "integer", "name": "string", "tags": "array", "expires": "string",
+		"opened": "string", "extra": "object", "NoTag": "boolean", "nested": "",
+	}
+	if len(props) != len(want) {
+		t.Errorf("got properties %v, want %v", props, want)
+	}
+	for name, typ := range want {
+		if p, ok := props[name]; !ok || p.Type != typ {
+			t.Errorf("got %s: %+v, want type %q", name, p, typ)
+		}
+	}
+	if props["expires"].Format != "date-time" || !props["opened"].Nullable {
+		t.Errorf("got expires %+v and opened %+v", props["expires"], props["opened"])
+	}
+	if props["nested"].Ref != "#/components/schemas/nested" || s["nested"] == nil {
+		t.Errorf("got nested %+v, want a reference to a component", props["nested"])
+	}
+}
+
+func TestSplitTemplate(t *testing.T) {
+	path, vars := splitTemplate("/exp/{year:[0-9]+}/{code:[a-z]{2}}/{name}/")
+	if path != "/exp/{year}/{code}/{name}/" {
+		t.Errorf("got path %q", path)
+	}
+	want := []pathVar{{"year", "[0-9]+"}, {"code", "[a-z]{2}"}, {"name", ""}}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("got vars %v, want %v", vars, want)
+	}
+}
+
+func newTestRouter() *mux.Router {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/items/", noop).Methods("GET", "POST")
+	router.HandleFunc("/items/{id:[0-9]+}/", noop).Methods("GET")
+	router.HandleFunc("/undocumented/", noop).Methods("DELETE")
+	return router
+}
+
+func TestGenerate(t *testing.T) {
+	doc, err := Generate(newTestRouter(), Info{Title: "test", Version: "1"}, Operations{
+		"GET /items/":      {Summary: "List", Response: []item{}, Deprecated: true},
+		"POST /items/":     {Summary: "Create", Auth: true, Request: item{}},
+		"GET /items/{id}/": {Summary: "Get", Response: item{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(doc.Paths) != 3 {
+		t.Errorf("got paths %v, want 3", doc.Paths)
+	}
+	create := doc.Paths["/items/"]["post"]
+	if create == nil || create.Security == nil || create.RequestBody == nil || create.Responses["401"].Description == "" {
+		t.Errorf("got create %+v, want authenticated operation with a body", create)
+	}
+	if list := doc.Paths["/items/"]["get"]; list == nil || !list.Deprecated {
+		t.Errorf("got list %+v, want deprecated operation", list)
+	}
+	get := doc.Paths["/items/{id}/"]["get"]
+	if get == nil || len(get.Parameters) != 1 || get.Parameters[0].Schema.Type != "integer" || !get.Parameters[0].Required {
+		t.Errorf("got get %+v, want integer id parameter", get)
+	}
+	if doc.Paths["/undocumented/"]["delete"] == nil {
+		t.Error("undocumented route missing")
+	}
+	if doc.Components.Schemas["item"] == nil {
+		t.Error("item schema missing from components")
+	}
+}
+
+func TestGenerateStale(t *testing.T) {
+	_, err := Generate(newTestRouter(), Info{}, Operations{"PUT /items/": {}})
+	if err == nil || !strings.Contains(err.Error(), "PUT /items/") {
+		t.Errorf("got %v, want error naming the stale operation", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	router := newTestRouter()
+	h := &Handler{Router: router, Info: Info{Title: "test"}}
+	router.Handle("/openapi.json", h)
+	router.HandleFunc("/late/", func(http.ResponseWriter, *http.Request) {}).Methods("GET")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/openapi.json", nil))
+	if rr.Code != http.StatusInternalServerError || strings.Contains(rr.Body.String(), "paths") {
+		t.Fatalf("got %d %s before Generate", rr.Code, rr.Body.String())
+	}
+
+	if err := h.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/openapi.json", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("got %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rr.Body.String(), `"/late/"`) {
+		t.Error("route registered after the handler is missing")
+	}
+
+	h.Operations = Operations{"PUT /items/": {}}
+	if err := h.Generate(); err == nil {
+		t.Error("Generate accepted a stale operation")
+	}
+}
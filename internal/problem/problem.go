@@ -0,0 +1,84 @@
+// RFC 7807 problem details for HTTP error responses.
+//
+// Every error response of the API is an application/problem+json object. Its
+// "code" member is a stable, machine readable identifier of the kind of error;
+// "detail" is meant for humans and may change.
+
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ContentType is the media type of problem responses.
+const ContentType = "application/problem+json"
+
+// Stable error codes.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeInvalidJSON          = "invalid_json"
+	CodeInvalidBody          = "invalid_body"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeValidation           = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeNotAcceptable        = "not_acceptable"
+	CodeConflict             = "conflict"
+	CodeBodyTooLarge         = "body_too_large"
+	CodeRateLimited          = "rate_limited"
+	CodeInternal             = "internal_error"
+	CodeTimeout              = "timeout"
+)
+
+// Problem is a problem details object. Type is always "about:blank", so Title
+// is the HTTP status text; Code and Errors are extension members.
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Code     string      `json:"code"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Errors   interface{} `json:"errors,omitempty"` // field errors of validation problems
+}
+
+// New creates a problem with the given status, code and human readable detail.
+func New(status int, code string, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
+
+func (p *Problem) Error() string {
+	return p.Detail
+}
+
+// Write renders p into w, with the path of req as its instance.
+func Write(w http.ResponseWriter, req *http.Request, p *Problem) {
+	if p.Instance == "" && req != nil {
+		p.Instance = req.URL.Path
+	}
+	js, err := json.Marshal(p)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	w.Write(js)
+}
+
+// Handler responds to every request with a problem with the given status and
+// code. It suits router fallbacks such as not found handlers.
+func Handler(status int, code string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		Write(w, req, New(status, code, ""))
+	})
+}
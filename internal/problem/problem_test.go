@@ -0,0 +1,42 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/food/7/", nil)
+	Write(rr, req, New(http.StatusNotFound, CodeNotFound, "food with id=7 not found"))
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("got status %d, want %d", rr.Code, http.StatusNotFound)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("got Content-Type %q, want %q", ct, ContentType)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"type":     "about:blank",
+		"title":    "Not Found",
+		"status":   float64(404),
+		"code":     "not_found",
+		"detail":   "food with id=7 not found",
+		"instance": "/food/7/",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("got %s=%v, want %v", k, got[k], v)
+		}
+	}
+	if _, ok := got["errors"]; ok {
+		t.Error("got errors member, want it omitted")
+	}
+}
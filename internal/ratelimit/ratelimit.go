@@ -0,0 +1,281 @@
+// Rate limiting of requests with token buckets.
+//
+// Every user, or client address for anonymous requests, has a bucket per
+// policy holding up to Burst tokens, refilled at Requests per Period. Each
+// request takes a token; requests finding the bucket empty are refused with
+// 429 Too Many Requests. Responses report the bucket in the RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers of the IETF draft on rate
+// limit headers.
+//
+// Verifying credentials hashes the password, which takes a while on purpose,
+// so buckets are checked first: see Limiter.Take.
+
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/diorchen/rest-server/internal/config"
+	"github.com/diorchen/rest-server/internal/middleware"
+	"github.com/diorchen/rest-server/internal/problem"
+)
+
+// Limiter keeps the buckets of one policy, by key.
+type Limiter struct {
+	sync.Mutex
+	rate  float64 // tokens added per second
+	burst float64 // size of the buckets
+
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	sweepFreq time.Duration    // how often full buckets are dropped
+	now       func() time.Time // clock, replaceable in tests
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed    bool
+	Limit      int           // size of the bucket
+	Remaining  int           // tokens left
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until a token is available, if none was
+}
+
+// NewLimiter returns a limiter of p, which must allow some requests.
+func NewLimiter(p config.Policy) *Limiter {
+	burst := p.Burst
+	if burst == 0 {
+		burst = p.Requests
+	}
+	return &Limiter{
+		rate:      float64(p.Requests) / time.Duration(p.Period).Seconds(),
+		burst:     float64(burst),
+		buckets:   make(map[string]*bucket),
+		sweepFreq: time.Duration(p.Period),
+		now:       time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of key, if there is one left.
+func (l *Limiter) Allow(key string) Result {
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	r := Result{Limit: int(l.burst)}
+	if b.tokens >= 1 {
+		b.tokens--
+		r.Allowed = true
+	} else {
+		r.RetryAfter = l.refill(1 - b.tokens)
+	}
+	r.Remaining = int(b.tokens)
+	r.Reset = l.refill(l.burst - b.tokens)
+	return r
+}
+
+// refund gives back a token taken from the bucket of key.
+func (l *Limiter) refund(key string) {
+	l.Lock()
+	defer l.Unlock()
+
+	if b, ok := l.buckets[key]; ok {
+		b.tokens = min(l.burst, b.tokens+1)
+	}
+}
+
+// Client is who a request counts against.
+type Client struct {
+	Addr string // address, without the port
+	User string // user claimed by the credentials, not verified yet
+}
+
+// Take takes a token for a request of c, and returns the result of the bucket
+// it counts against. Requests without a user count against the address.
+// Requests claiming a user take a token from the address first, then from the
+// user, and verify checks their credentials: the user keeps its token only if
+// they're valid, and the address gets its token back then. Failed attempts
+// thus count against the address, whatever users they claim, without locking
+// the users out, and requests over either limit are refused without hashing a
+// password.
+func (l *Limiter) Take(c Client, verify func() bool) (r Result, key string) {
+	addr := "addr:" + c.Addr
+	if r = l.Allow(addr); !r.Allowed || c.User == "" {
+		return r, addr
+	}
+	key = "user:" + c.User
+	if r = l.Allow(key); !r.Allowed {
+		l.refund(addr)
+		return r, key
+	}
+	if verify() {
+		l.refund(addr)
+	} else {
+		l.refund(key)
+	}
+	return r, key
+}
+
+// refill returns how long it takes to add tokens to a bucket.
+func (l *Limiter) refill(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// sweep drops the buckets that are full again, which are as good as new, so
+// that clients passing by don't take memory for good. The caller must hold
+// the lock.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.sweepFreq {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// route is the policy of a route; limiter is nil if it has no limit.
+type route struct {
+	path    string
+	methods []string
+	limiter *Limiter
+}
+
+// versionPrefix matches the prefixes of the API versions.
+var versionPrefix = regexp.MustCompile(`^/v[0-9]+$`)
+
+func (r route) matches(tpl string, method string) bool {
+	if len(r.methods) > 0 && !slices.ContainsFunc(r.methods, func(m string) bool { return strings.EqualFold(m, method) }) {
+		return false
+	}
+	prefix, ok := strings.CutSuffix(tpl, r.path)
+	return ok && (prefix == "" || versionPrefix.MatchString(prefix))
+}
+
+// RateLimiter limits requests by the policies of a configuration.
+type RateLimiter struct {
+	read   *Limiter
+	write  *Limiter
+	routes []route
+}
+
+// New returns a rate limiter enforcing cfg.
+func New(cfg config.RateLimit) *RateLimiter {
+	rl := &RateLimiter{read: newLimiter(cfg.Read), write: newLimiter(cfg.Write)}
+	for _, r := range cfg.Routes {
+		rl.routes = append(rl.routes, route{path: r.Route, methods: r.Methods, limiter: newLimiter(r.Limit)})
+	}
+	return rl
+}
+
+// Limiter returns the limiter of the read or the write policy, or nil if it
+// has no limit, for requests that aren't routed, like gRPC calls. rl may be
+// nil, for no limits.
+func (rl *RateLimiter) Limiter(write bool) *Limiter {
+	switch {
+	case rl == nil:
+		return nil
+	case write:
+		return rl.write
+	}
+	return rl.read
+}
+
+func newLimiter(p config.Policy) *Limiter {
+	if p.Requests == 0 {
+		return nil
+	}
+	return NewLimiter(p)
+}
+
+// limiter returns the limiter of the route req matched, or nil if it has no
+// limit.
+func (rl *RateLimiter) limiter(req *http.Request) *Limiter {
+	if current := mux.CurrentRoute(req); current != nil {
+		if tpl, err := current.GetPathTemplate(); err == nil {
+			for _, r := range rl.routes {
+				if r.matches(tpl, req.Method) {
+					return r.limiter
+				}
+			}
+		}
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return rl.read
+	}
+	return rl.write
+}
+
+// Middleware is router middleware refusing the requests over the limit of
+// their route. Requests with basic auth credentials for routes checking them
+// count against their user, and are authenticated here, once their buckets
+// allow it; other requests count against their client's address.
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		limiter := rl.limiter(req)
+		if limiter == nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		host, _, _ := net.SplitHostPort(req.RemoteAddr)
+		c := Client{Addr: host}
+		var verify func() bool
+		if user, _, ok := req.BasicAuth(); ok && middleware.Authenticates(req) {
+			c.User = user
+			verify = func() bool {
+				// BasicAuth finds the outcome in the request.
+				req, user = middleware.Authenticate(req)
+				return user != ""
+			}
+		}
+		r, key := limiter.Take(c, verify)
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(r.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(r.Remaining))
+		h.Set("RateLimit-Reset", seconds(r.Reset))
+		if !r.Allowed {
+			middleware.Logger(req.Context()).Warn("rate limited", "key", key)
+			h.Set("Retry-After", seconds(r.RetryAfter))
+			problem.Write(w, req, problem.New(http.StatusTooManyRequests, problem.CodeRateLimited,
+				fmt.Sprintf("too many requests, retry in %s seconds", seconds(r.RetryAfter))))
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// seconds formats d as a number of whole seconds, rounded up so that clients
+// waiting that long find a token.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/diorchen/rest-server/internal/config"
+	"github.com/diorchen/rest-server/internal/middleware"
+	"github.com/diorchen/rest-server/internal/problem"
+)
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(config.Policy{Requests: 1, Period: config.Duration(time.Second), Burst: 2})
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if r := l.Allow("joe"); !r.Allowed || r.Remaining != 1-i {
+			t.Fatalf("request %d of the burst: got %+v", i, r)
+		}
+	}
+	r := l.Allow("joe")
+	if r.Allowed || r.RetryAfter != time.Second || r.Reset != 2*time.Second {
+		t.Errorf("request over the burst: got %+v, want to retry in 1s", r)
+	}
+	if r := l.Allow("mary"); !r.Allowed {
+		t.Errorf("other key: got %+v, want a bucket of its own", r)
+	}
+
+	now = now.Add(1500 * time.Millisecond)
+	if r := l.Allow("joe"); !r.Allowed || r.Remaining != 0 {
+		t.Errorf("after refilling: got %+v", r)
+	}
+	now = now.Add(time.Minute)
+	l.Allow("joe")
+	if len(l.buckets) != 1 {
+		t.Errorf("got %d buckets, want mary's full bucket dropped", len(l.buckets))
+	}
+}
+
+func TestTake(t *testing.T) {
+	l := NewLimiter(config.Policy{Requests: 2, Period: config.Duration(time.Minute)})
+	verified := 0
+	valid := false
+	verify := func() bool { verified++; return valid }
+
+	// Failed attempts count against the address, whatever user they claim,
+	// and stop being verified once it's out of tokens.
+	for i, user := range []string{"joe", "mary", "bob"} {
+		r, key := l.Take(Client{Addr: "192.0.2.1", User: user}, verify)
+		if want := i < 2; r.Allowed != want {
+			t.Errorf("attempt %d as %s: got %+v from %s, want allowed %v", i, user, r, key, want)
+		}
+	}
+	if verified != 2 {
+		t.Errorf("verified %d attempts, want 2", verified)
+	}
+	if r, key := l.Take(Client{Addr: "192.0.2.1"}, nil); r.Allowed || key != "addr:192.0.2.1" {
+		t.Errorf("anonymous request: got %+v from %s, want the address out of tokens", r, key)
+	}
+
+	// Valid credentials give the address its token back.
+	valid = true
+	for i := 0; i < 2; i++ {
+		if r, key := l.Take(Client{Addr: "192.0.2.2", User: "alice"}, verify); !r.Allowed || key != "user:alice" {
+			t.Errorf("request %d as alice: got %+v from %s", i, r, key)
+		}
+	}
+	if r, _ := l.Take(Client{Addr: "192.0.2.2", User: "alice"}, verify); r.Allowed {
+		t.Errorf("alice over her limit: got %+v", r)
+	}
+	if verified != 4 {
+		t.Errorf("verified %d attempts, want alice's request over the limit not verified", verified)
+	}
+	if r, _ := l.Take(Client{Addr: "192.0.2.2"}, nil); !r.Allowed || r.Remaining != 1 {
+		t.Errorf("anonymous request from alice's address: got %+v, want a full bucket", r)
+	}
+
+	// Bad passwords for joe, from any address, don't lock joe out.
+	valid = false
+	for i := 0; i < 4; i++ {
+		l.Take(Client{Addr: fmt.Sprintf("198.51.100.%d", i), User: "joe"}, verify)
+	}
+	valid = true
+	for i := 0; i < 2; i++ {
+		if r, key := l.Take(Client{Addr: "192.0.2.3", User: "joe"}, verify); !r.Allowed || key != "user:joe" {
+			t.Errorf("request %d as joe after bad passwords: got %+v from %s", i, r, key)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	rl := New(config.RateLimit{
+		Read:  config.Policy{Requests: 2, Period: config.Duration(time.Minute)},
+		Write: config.Policy{Requests: 1, Period: config.Duration(time.Minute)},
+		Routes: []config.RoutePolicy{
+			{Route: "/healthz"},
+			{Route: "/import", Methods: []string{"post"}, Limit: config.Policy{Requests: 5, Period: config.Duration(time.Hour)}},
+		},
+	})
+	router := mux.NewRouter()
+	router.Use(rl.Middleware)
+	router.HandleFunc("/food/", func(http.ResponseWriter, *http.Request) {}).Methods("GET", "POST")
+	router.Handle("/inbox/", middleware.BasicAuth(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})))
+	router.HandleFunc("/healthz", func(http.ResponseWriter, *http.Request) {})
+	router.HandleFunc("/v1/import", func(http.ResponseWriter, *http.Request) {})
+
+	serve := func(method string, path string, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if user != "" {
+			req.SetBasicAuth(user, "1234")
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	serve("GET", "/food/", "")
+	rr := serve("GET", "/food/", "")
+	if rr.Code != http.StatusOK || rr.Header().Get("RateLimit-Limit") != "2" || rr.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("last read allowed: got %d with headers %v", rr.Code, rr.Header())
+	}
+	rr = serve("GET", "/food/", "")
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "30" {
+		t.Errorf("read over the limit: got %d with headers %v", rr.Code, rr.Header())
+	}
+	if rr.Header().Get("Content-Type") != problem.ContentType {
+		t.Errorf("got Content-Type %q, want a problem", rr.Header().Get("Content-Type"))
+	}
+
+	// Writes have limits of their own, and users buckets of their own where
+	// their credentials are checked; elsewhere, credentials don't matter.
+	if rr := serve("POST", "/food/", ""); rr.Code != http.StatusOK {
+		t.Errorf("first write: got %d", rr.Code)
+	}
+	if rr := serve("GET", "/food/", "joe"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("read with credentials of a route not checking them: got %d", rr.Code)
+	}
+	req := httptest.NewRequest("GET", "/inbox/", nil)
+	req.RemoteAddr = "192.0.2.2:1234" // the test address is out of tokens
+	req.SetBasicAuth("joe", "1234")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || rr.Header().Get("RateLimit-Remaining") != "1" {
+		t.Errorf("read of an authenticated user: got %d with headers %v", rr.Code, rr.Header())
+	}
+
+	for i := 0; i < 3; i++ {
+		if rr := serve("GET", "/healthz", ""); rr.Code != http.StatusOK || rr.Header().Get("RateLimit-Limit") != "" {
+			t.Errorf("unlimited route: got %d with headers %v", rr.Code, rr.Header())
+		}
+	}
+	if rr := serve("POST", "/v1/import", ""); rr.Header().Get("RateLimit-Limit") != "5" {
+		t.Errorf("versioned route: got headers %v, want the route's policy", rr.Header())
+	}
+}
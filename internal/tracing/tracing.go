@@ -0,0 +1,109 @@
+// Tracing of requests with OpenTelemetry.
+//
+// Setup installs the global tracer provider, which the store and the
+// middleware use for their spans, and the W3C trace context propagator.
+// Middleware starts a span per request, continuing the trace of the client if
+// it sent a traceparent header.
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/diorchen/rest-server/internal/middleware"
+)
+
+// ServiceName names the server in the exported spans.
+const ServiceName = "rest-server"
+
+// Exporters Setup can send spans to.
+const (
+	ExporterNone   = "none"   // spans are not recorded
+	ExporterStdout = "stdout" // spans are written to Options.Stdout as JSON
+	ExporterOTLP   = "otlp"   // spans are sent to an OTLP collector over gRPC
+)
+
+// Options select where spans go.
+type Options struct {
+	Exporter    string    // one of the Exporter constants
+	Endpoint    string    // host:port of the OTLP collector, reached without TLS
+	SampleRatio float64   // fraction of new traces recorded; traces of clients follow their sampling decision
+	Stdout      io.Writer // destination of the stdout exporter
+}
+
+// Setup installs the global tracer provider and propagator. The returned
+// shutdown function flushes the spans not exported yet.
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if opts.SampleRatio < 0 || opts.SampleRatio > 1 {
+		return nil, fmt.Errorf("trace sample ratio %v isn't between 0 and 1", opts.SampleRatio)
+	}
+
+	var exporter sdktrace.SpanExporter
+	switch opts.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(opts.Stdout))
+	case ExporterOTLP:
+		exporter, err = otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(opts.Endpoint), otlptracegrpc.WithInsecure())
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q, expect %s, %s or %s", opts.Exporter, ExporterNone, ExporterStdout, ExporterOTLP)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+var tracer = otel.Tracer("github.com/diorchen/rest-server/internal/tracing")
+
+// Middleware traces every request passed to next in a server span, named
+// after the method until middleware.Route names it after the matched route.
+// Server errors mark the span as failed.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		client, _, _ := net.SplitHostPort(req.RemoteAddr)
+		ctx, span := tracer.Start(ctx, req.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(req.Method),
+				semconv.URLPath(req.URL.Path),
+				semconv.ClientAddress(client),
+				semconv.UserAgentOriginal(req.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		sw := middleware.NewStatusWriter(w)
+		next.ServeHTTP(sw, req.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(sw.Status()))
+		if sw.Status() >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(sw.Status()))
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/middleware"
+)
+
+func TestSetup(t *testing.T) {
+	if _, err := Setup(context.Background(), Options{Exporter: "jaeger", SampleRatio: 1}); err == nil {
+		t.Error("unknown exporter, got no error")
+	}
+	if _, err := Setup(context.Background(), Options{Exporter: ExporterNone, SampleRatio: 2}); err == nil {
+		t.Error("sample ratio over 1, got no error")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	store := groceryItemStore.New()
+	router := mux.NewRouter()
+	router.Use(middleware.Route)
+	router.HandleFunc("/food/{id:[0-9]+}/", func(w http.ResponseWriter, req *http.Request) {
+		store.GetFood(req.Context(), 7)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	var log bytes.Buffer
+	logger, _ := middleware.NewLogger(&log, "info", "json")
+	h := Middleware(middleware.Logging(logger)(router))
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest("GET", "/food/7/", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want the store's and the request's", len(spans))
+	}
+	op, server := spans[0], spans[1]
+	if server.Name() != "GET /food/{id:[0-9]+}/" || server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("got server span %q of kind %v", server.Name(), server.SpanKind())
+	}
+	if server.SpanContext().TraceID().String() != traceID || server.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("server span doesn't continue the client's trace: %v", server.SpanContext())
+	}
+	if op.Name() != "GroceryItemStore.GetFood" || op.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Errorf("got store span %q with parent %v, want a child of the request", op.Name(), op.Parent().SpanID())
+	}
+	if !strings.Contains(log.String(), `"trace_id":"`+traceID+`"`) {
+		t.Errorf("log %q lacks the trace ID", log.String())
+	}
+}
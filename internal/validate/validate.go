@@ -0,0 +1,182 @@
+// Declarative validation of request payloads.
+//
+// Rules are declared in `validate` struct tags as a comma separated list:
+//
+//	required    the value isn't zero; strings aren't blank, slices aren't empty
+//	min=N       numbers are at least N; strings and slices have at least N
+//	            characters or items
+//	max=N       like min, but at most N
+//	unique      slices have no duplicate items
+//	within=N    times are within N years of now
+//	dive        the rules that follow apply to each item of a slice
+//
+// Fields of struct type are validated recursively. Fields are named in errors
+// by their JSON names, as in "nutrition.calories" or "ingredients[1]".
+
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError is a rule violated by a field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors is every FieldError of a value.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + " " + fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// now is replaced in tests.
+var now = time.Now
+
+// Struct checks the fields of the struct v, or the struct v points to, against
+// their rules. It returns Errors listing all violations, or nil. Malformed
+// rules are programming errors and panic.
+func Struct(v interface{}) error {
+	val := reflect.Indirect(reflect.ValueOf(v))
+	if val.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validate: expect struct, got %T", v))
+	}
+
+	var errs Errors
+	checkStruct(val, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func checkStruct(val reflect.Value, prefix string, errs *Errors) {
+	t := val.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		checkValue(val.Field(i), prefix+name, splitRules(f.Tag.Get("validate")), errs)
+	}
+}
+
+func splitRules(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	return strings.Split(tag, ",")
+}
+
+func checkValue(v reflect.Value, field string, rules []string, errs *Errors) {
+	for i, rule := range rules {
+		name, arg, _ := strings.Cut(rule, "=")
+		if name == "dive" {
+			if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+				panic(fmt.Sprintf("validate: dive on %s of kind %s", field, v.Kind()))
+			}
+			for j := 0; j < v.Len(); j++ {
+				checkValue(v.Index(j), fmt.Sprintf("%s[%d]", field, j), rules[i+1:], errs)
+			}
+			return
+		}
+		if msg := check(v, name, arg); msg != "" {
+			*errs = append(*errs, FieldError{Field: field, Message: msg})
+			if name == "required" {
+				// The other rules are moot for a missing value.
+				return
+			}
+		}
+	}
+
+	if v.Kind() == reflect.Struct && v.Type() != timeType {
+		checkStruct(v, field+".", errs)
+	}
+}
+
+// check applies one rule to v, returning the violation or "".
+func check(v reflect.Value, rule string, arg string) string {
+	switch rule {
+	case "required":
+		if v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "" ||
+			(v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0 ||
+			v.IsZero() {
+			return "is required"
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validate: bad %s=%q", rule, arg))
+		}
+		n, format := measure(v, rule)
+		if rule == "min" && n < limit {
+			return fmt.Sprintf(format, "at least "+arg)
+		}
+		if rule == "max" && n > limit {
+			return fmt.Sprintf(format, "at most "+arg)
+		}
+	case "unique":
+		seen := map[interface{}]bool{}
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i).Interface()
+			if seen[item] {
+				return fmt.Sprintf("has duplicate item %v", item)
+			}
+			seen[item] = true
+		}
+	case "within":
+		years, err := strconv.Atoi(arg)
+		if err != nil || v.Type() != timeType {
+			panic(fmt.Sprintf("validate: bad within=%q on %s", arg, v.Type()))
+		}
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return ""
+		}
+		today := now()
+		if t.Before(today.AddDate(-years, 0, 0)) || t.After(today.AddDate(years, 0, 0)) {
+			return fmt.Sprintf("must be within %d years of today", years)
+		}
+	default:
+		panic(fmt.Sprintf("validate: unknown rule %q", rule))
+	}
+	return ""
+}
+
+// measure returns the quantity min and max compare, with the format of the
+// message reporting a violated limit.
+func measure(v reflect.Value, rule string) (float64, string) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "must be %s"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), "must be %s"
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "must be %s"
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), "must be %s characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), "must have %s items"
+	}
+	panic(fmt.Sprintf("validate: %s on kind %s", rule, v.Kind()))
+}
@@ -0,0 +1,79 @@
+package validate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type inner struct {
+	Calories int     `json:"calories" validate:"min=0,max=100"`
+	Fat      float64 `json:"fat" validate:"min=0"`
+}
+
+type payload struct {
+	Name        string    `json:"name" validate:"required,max=5"`
+	Ingredients []string  `json:"ingredients" validate:"unique,max=3,dive,required,max=4"`
+	Expiration  time.Time `json:"expiration" validate:"required,within=10"`
+	Inner       inner     `json:"inner"`
+	Untagged    string
+}
+
+func TestStruct(t *testing.T) {
+	today := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return today }
+	defer func() { now = time.Now }()
+
+	valid := payload{Name: "Milk", Ingredients: []string{"Milk"}, Expiration: today}
+	if err := Struct(valid); err != nil {
+		t.Errorf("got %v for valid payload", err)
+	}
+	if err := Struct(&valid); err != nil {
+		t.Errorf("got %v for pointer to valid payload", err)
+	}
+
+	tests := []struct {
+		name string
+		p    payload
+		want Errors
+	}{
+		{"missing", payload{Name: "  "}, Errors{
+			{"name", "is required"},
+			{"expiration", "is required"},
+		}},
+		{"limits", payload{
+			Name:        "Cheese",
+			Ingredients: []string{"Milk", "Milk", "", "Rennet"},
+			Expiration:  today.AddDate(11, 0, 0),
+			Inner:       inner{Calories: 101, Fat: -1},
+		}, Errors{
+			{"name", "must be at most 5 characters long"},
+			{"ingredients", "has duplicate item Milk"},
+			{"ingredients", "must have at most 3 items"},
+			{"ingredients[2]", "is required"},
+			{"ingredients[3]", "must be at most 4 characters long"},
+			{"expiration", "must be within 10 years of today"},
+			{"inner.calories", "must be at most 100"},
+			{"inner.fat", "must be at least 0"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Struct(tt.p)
+			if got, _ := err.(Errors); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnknownRulePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expect panic for unknown rule")
+		}
+	}()
+	Struct(struct {
+		A int `validate:"positive"`
+	}{})
+}
@@ -0,0 +1,185 @@
+// Filtered live views over the food in the store.
+//
+// A View keeps track of which food items match a Filter and turns store events
+// into diffs (added, changed, removed) against that set, so streaming clients
+// only see the items they asked for.
+
+package watch
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+)
+
+// Filter selects food items. All the set fields have to match; the zero Filter
+// matches everything.
+type Filter struct {
+	// Contains matches items whose name, description or any ingredient
+	// contains the string, ignoring case.
+	Contains string `json:"contains,omitempty"`
+
+	Ingredient string `json:"ingredient,omitempty"` // exact ingredient
+	Category   string `json:"category,omitempty"`
+	Location   int    `json:"location,omitempty"`
+
+	// ExpiresWithinHours matches items whose effective expiration is less
+	// than this many hours away, including already expired ones.
+	ExpiresWithinHours int `json:"expiresWithinHours,omitempty"`
+}
+
+// Validate reports whether f is a usable filter.
+func (f Filter) Validate() error {
+	if f.ExpiresWithinHours < 0 {
+		return fmt.Errorf("expiresWithinHours must not be negative, got %d", f.ExpiresWithinHours)
+	}
+	if f.Location < 0 {
+		return fmt.Errorf("location must not be negative, got %d", f.Location)
+	}
+	return nil
+}
+
+// TimeDependent reports whether items can start matching f just by time
+// passing, without any change to the store.
+func (f Filter) TimeDependent() bool {
+	return f.ExpiresWithinHours > 0
+}
+
+// Match reports whether food matches f at time now.
+func (f Filter) Match(food groceryItemStore.FoodItem, now time.Time) bool {
+	if f.Contains != "" && !containsFold(food, f.Contains) {
+		return false
+	}
+	if f.Ingredient != "" {
+		found := false
+		for _, ing := range food.Ingredients {
+			if ing == f.Ingredient {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Category != "" && food.Category != f.Category {
+		return false
+	}
+	if f.Location != 0 && food.Location != f.Location {
+		return false
+	}
+	if f.ExpiresWithinHours > 0 {
+		if food.EffectiveExpiration.IsZero() ||
+			food.EffectiveExpiration.Sub(now) >= time.Duration(f.ExpiresWithinHours)*time.Hour {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(food groceryItemStore.FoodItem, s string) bool {
+	s = strings.ToLower(s)
+	if strings.Contains(strings.ToLower(food.Name), s) || strings.Contains(strings.ToLower(food.Description), s) {
+		return true
+	}
+	for _, ing := range food.Ingredients {
+		if strings.Contains(strings.ToLower(ing), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Kinds of Diff.
+const (
+	Added   = "added"
+	Changed = "changed"
+	Removed = "removed"
+)
+
+// Diff is a change to the set of items matching a View's filter. Food is nil
+// for removals.
+type Diff struct {
+	Op     string                     `json:"op"`
+	FoodId int                        `json:"foodId"`
+	Food   *groceryItemStore.FoodItem `json:"food,omitempty"`
+}
+
+// View tracks the items matching a filter. A View is not safe for concurrent
+// use.
+type View struct {
+	Filter  Filter
+	matched map[int]bool
+}
+
+// NewView creates a view of items under filter and returns the matching ones.
+func NewView(filter Filter, items []groceryItemStore.FoodItem, now time.Time) (*View, []groceryItemStore.FoodItem) {
+	v := &View{Filter: filter}
+	return v, v.Reset(items, now)
+}
+
+// Reset forgets what the view has seen, starts over from items and returns the
+// matching ones.
+func (v *View) Reset(items []groceryItemStore.FoodItem, now time.Time) []groceryItemStore.FoodItem {
+	v.matched = make(map[int]bool)
+	matching := []groceryItemStore.FoodItem{}
+	for _, food := range items {
+		if v.Filter.Match(food, now) {
+			v.matched[food.Id] = true
+			matching = append(matching, food)
+		}
+	}
+	return matching
+}
+
+// Apply updates the view with a store event and returns the resulting diff, if
+// any.
+func (v *View) Apply(ev groceryItemStore.Event, now time.Time) (Diff, bool) {
+	if ev.Food == nil {
+		return Diff{}, false
+	}
+	if ev.Type == groceryItemStore.FoodDeleted {
+		return v.update(ev.Food.Id, nil, now)
+	}
+	return v.update(ev.Food.Id, ev.Food, now)
+}
+
+// Refresh re-evaluates the view against all items, catching items that started
+// or stopped matching because time passed, and returns the diffs.
+func (v *View) Refresh(items []groceryItemStore.FoodItem, now time.Time) []Diff {
+	var diffs []Diff
+	present := make(map[int]bool, len(items))
+	for i := range items {
+		present[items[i].Id] = true
+		if d, ok := v.update(items[i].Id, &items[i], now); ok && d.Op != Changed {
+			diffs = append(diffs, d)
+		}
+	}
+	for id := range v.matched {
+		if !present[id] {
+			d, _ := v.update(id, nil, now)
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+// update records the new state of item id (nil if it's gone).
+func (v *View) update(id int, food *groceryItemStore.FoodItem, now time.Time) (Diff, bool) {
+	was := v.matched[id]
+	is := food != nil && v.Filter.Match(*food, now)
+
+	switch {
+	case is && !was:
+		v.matched[id] = true
+		return Diff{Op: Added, FoodId: id, Food: food}, true
+	case is && was:
+		return Diff{Op: Changed, FoodId: id, Food: food}, true
+	case !is && was:
+		delete(v.matched, id)
+		return Diff{Op: Removed, FoodId: id}, true
+	}
+	return Diff{}, false
+}
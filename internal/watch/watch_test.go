@@ -0,0 +1,86 @@
+package watch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+)
+
+func TestFilterMatch(t *testing.T) {
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	yogurt := groceryItemStore.FoodItem{
+		Name:                "Greek yogurt",
+		Ingredients:         []string{"Milk", "Cultures"},
+		Category:            "dairy",
+		Location:            2,
+		EffectiveExpiration: now.Add(36 * time.Hour),
+	}
+
+	var tests = []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty", Filter{}, true},
+		{"contains name", Filter{Contains: "YOGURT"}, true},
+		{"contains ingredient", Filter{Contains: "cult"}, true},
+		{"contains nothing", Filter{Contains: "cheese"}, false},
+		{"ingredient", Filter{Ingredient: "Milk"}, true},
+		{"ingredient is exact", Filter{Ingredient: "milk"}, false},
+		{"category", Filter{Category: "dairy"}, true},
+		{"location", Filter{Location: 3}, false},
+		{"expires within 2 days", Filter{ExpiresWithinHours: 48}, true},
+		{"expires within 1 day", Filter{ExpiresWithinHours: 24}, false},
+		{"all", Filter{Contains: "greek", Category: "dairy", Location: 2, ExpiresWithinHours: 48}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(yogurt, now); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestViewDiffs(t *testing.T) {
+	gis := groceryItemStore.New()
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	var events []groceryItemStore.Event
+	gis.Subscribe(func(ev groceryItemStore.Event) { events = append(events, ev) })
+
+	milk, _ := gis.AddFood(context.Background(), groceryItemStore.FoodItem{Name: "Milk", Category: "dairy", Expiration: now.Add(72 * time.Hour)})
+	all, _ := gis.GetAllFood(context.Background())
+	v, snapshot := NewView(Filter{Category: "dairy", ExpiresWithinHours: 48}, all, now)
+	if len(snapshot) != 0 {
+		t.Fatalf("got snapshot %v, want empty", snapshot)
+	}
+
+	// Milk starts matching once it's less than two days from expiring.
+	now = now.Add(25 * time.Hour)
+	all, _ = gis.GetAllFood(context.Background())
+	diffs := v.Refresh(all, now)
+	if len(diffs) != 1 || diffs[0].Op != Added || diffs[0].FoodId != milk {
+		t.Fatalf("got %v, want milk added", diffs)
+	}
+	if diffs := v.Refresh(all, now); len(diffs) != 0 {
+		t.Fatalf("got %v on second refresh, want nothing", diffs)
+	}
+
+	events = nil
+	gis.OpenFood(context.Background(), milk)
+	gis.AddFood(context.Background(), groceryItemStore.FoodItem{Name: "Rice", Category: "grains"})
+	gis.DeleteFood(context.Background(), milk)
+
+	var ops []string
+	for _, ev := range events {
+		if d, ok := v.Apply(ev, now); ok {
+			ops = append(ops, d.Op)
+		}
+	}
+	if len(ops) != 2 || ops[0] != Changed || ops[1] != Removed {
+		t.Errorf("got ops %v, want [changed removed]", ops)
+	}
+}
@@ -0,0 +1,491 @@
+// Outgoing webhooks for store events.
+//
+// A Manager keeps webhook subscriptions and delivers every matching store event
+// to them as an HMAC-signed JSON POST. Each subscription has a queue of events,
+// delivered in order by a worker of its own, so a slow receiver holds up only
+// its own events. Failed deliveries are retried with exponential backoff;
+// deliveries that still fail, and events that find the queue full, end up in a
+// dead-letter queue from where they can be retried by hand.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/validate"
+)
+
+// ErrBacklogFull is the error of events that didn't fit in the queue of a
+// subscription.
+var ErrBacklogFull = errors.New("too many deliveries pending")
+
+// SignatureHeader carries the hex encoded HMAC-SHA256 of the request body,
+// keyed with the subscription's secret, as "sha256=<hex>".
+const SignatureHeader = "X-Webhook-Signature"
+
+// Subscription asks for events of the given types to be POSTed to URL. An
+// empty Events list subscribes to all events.
+type Subscription struct {
+	Id      int       `json:"id"`
+	URL     string    `json:"url"`
+	Secret  string    `json:"-"`
+	Events  []string  `json:"events"`
+	Created time.Time `json:"created"`
+}
+
+func (s Subscription) wants(typ string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, ev := range s.Events {
+		if ev == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is one attempt to POST an event to a subscription.
+type Delivery struct {
+	Id             int       `json:"id"`
+	SubscriptionId int       `json:"subscriptionId"`
+	EventId        int       `json:"eventId"`
+	EventType      string    `json:"eventType"`
+	Attempt        int       `json:"attempt"`
+	Time           time.Time `json:"time"`
+	StatusCode     int       `json:"statusCode,omitempty"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// DeadLetter is an event that couldn't be delivered to a subscription within
+// the allowed number of attempts.
+type DeadLetter struct {
+	Id             int             `json:"id"`
+	SubscriptionId int             `json:"subscriptionId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	Error          string          `json:"error"`
+	Time           time.Time       `json:"time"`
+}
+
+// Options tune delivery of webhooks.
+type Options struct {
+	MaxAttempts    int           // attempts before a delivery goes to the dead-letter queue
+	InitialBackoff time.Duration // wait before the first retry, doubled for every further retry
+	MaxLogSize     int           // number of deliveries kept per subscription in the log
+	Backlog        int           // number of events queued per subscription
+	MaxDeadLetters int           // number of dead letters kept, dropping the oldest
+	Client         *http.Client
+}
+
+// DefaultOptions are the Options used by the server.
+var DefaultOptions = Options{
+	MaxAttempts:    5,
+	InitialBackoff: time.Second,
+	MaxLogSize:     100,
+	Backlog:        100,
+	MaxDeadLetters: 1000,
+	Client:         &http.Client{Timeout: 10 * time.Second},
+}
+
+// Manager keeps webhook subscriptions and delivers events to them; Manager
+// methods are safe to call concurrently.
+type Manager struct {
+	sync.Mutex
+
+	opts         Options
+	subs         map[int]Subscription
+	queues       map[int]chan event // pending events by subscription ID
+	nextSubId    int
+	deliveries   map[int][]Delivery // delivery log by subscription ID
+	nextDelivery int
+	dead         map[int]DeadLetter
+	nextDeadId   int
+	oldestDeadId int // no dead letter is older
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup // workers
+	pending sync.WaitGroup // queued and in-flight events
+}
+
+// event is an event queued for delivery.
+type event struct {
+	id      int
+	typ     string
+	payload []byte
+}
+
+// New creates a Manager delivering with the given options.
+func New(opts Options) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		opts:       opts,
+		subs:       make(map[int]Subscription),
+		queues:     make(map[int]chan event),
+		deliveries: make(map[int][]Delivery),
+		dead:       make(map[int]DeadLetter),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Close stops retrying pending deliveries and waits for in-flight ones to
+// finish. Queued events go to the dead-letter queue.
+func (m *Manager) Close() {
+	m.cancel()
+	m.Lock()
+	for id, queue := range m.queues {
+		close(queue)
+		delete(m.queues, id)
+	}
+	m.Unlock()
+	m.wg.Wait()
+}
+
+// Subscribe adds a subscription and returns its ID. The URL must be an absolute
+// http or https URL, and the events known types of groceryItemStore.Event;
+// otherwise the error is validate.Errors.
+func (m *Manager) Subscribe(url string, secret string, events []string) (int, error) {
+	if err := validSubscription(url, events); err != nil {
+		return 0, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.nextSubId++
+	sub := Subscription{
+		Id:      m.nextSubId,
+		URL:     url,
+		Secret:  secret,
+		Events:  append([]string(nil), events...),
+		Created: time.Now(),
+	}
+	m.subs[sub.Id] = sub
+	if m.ctx.Err() == nil {
+		queue := make(chan event, m.opts.Backlog)
+		m.queues[sub.Id] = queue
+		m.wg.Add(1)
+		go m.work(sub, queue)
+	}
+	return sub.Id, nil
+}
+
+func validSubscription(rawURL string, events []string) error {
+	var errs validate.Errors
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, validate.FieldError{Field: "url", Message: "must be an absolute http or https URL"})
+	}
+	for i, ev := range events {
+		if !slices.Contains(groceryItemStore.EventTypes, ev) {
+			errs = append(errs, validate.FieldError{Field: "events[" + strconv.Itoa(i) + "]", Message: fmt.Sprintf("is not an event type, like %q", groceryItemStore.FoodCreated)})
+		}
+	}
+	if errs != nil {
+		return errs
+	}
+	return nil
+}
+
+// GetSubscription retrieves a subscription by id. If no such id exists, an
+// error is returned.
+func (m *Manager) GetSubscription(id int) (Subscription, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	sub, ok := m.subs[id]
+	if !ok {
+		return Subscription{}, fmt.Errorf("webhook with id=%d not found", id)
+	}
+	return sub, nil
+}
+
+// GetAllSubscriptions returns all the subscriptions, in arbitrary order.
+func (m *Manager) GetAllSubscriptions() []Subscription {
+	m.Lock()
+	defer m.Unlock()
+
+	subs := make([]Subscription, 0, len(m.subs))
+	for _, sub := range m.subs {
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// Unsubscribe deletes the subscription with the given id along with its
+// delivery log. Its queued events are dropped.
+func (m *Manager) Unsubscribe(id int) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.subs[id]; !ok {
+		return fmt.Errorf("webhook with id=%d not found", id)
+	}
+	delete(m.subs, id)
+	delete(m.deliveries, id)
+	if queue, ok := m.queues[id]; ok {
+		close(queue)
+		delete(m.queues, id)
+	}
+	return nil
+}
+
+// GetDeliveries returns the delivery log of a subscription, oldest first.
+func (m *Manager) GetDeliveries(id int) ([]Delivery, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.subs[id]; !ok {
+		return nil, fmt.Errorf("webhook with id=%d not found", id)
+	}
+	log := make([]Delivery, len(m.deliveries[id]))
+	copy(log, m.deliveries[id])
+	return log, nil
+}
+
+// GetDeadLetters returns the dead-letter queue, in arbitrary order.
+func (m *Manager) GetDeadLetters() []DeadLetter {
+	m.Lock()
+	defer m.Unlock()
+
+	dead := make([]DeadLetter, 0, len(m.dead))
+	for _, dl := range m.dead {
+		dead = append(dead, dl)
+	}
+	return dead
+}
+
+// DeleteDeadLetter drops a dead letter without delivering it.
+func (m *Manager) DeleteDeadLetter(id int) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.dead[id]; !ok {
+		return fmt.Errorf("dead letter with id=%d not found", id)
+	}
+	delete(m.dead, id)
+	return nil
+}
+
+// RetryDeadLetter takes a dead letter off the queue and delivers it again,
+// with a fresh set of attempts. It fails with ErrBacklogFull if the
+// subscription's queue is full.
+func (m *Manager) RetryDeadLetter(id int) error {
+	m.Lock()
+	defer m.Unlock()
+
+	dl, ok := m.dead[id]
+	if !ok {
+		return fmt.Errorf("dead letter with id=%d not found", id)
+	}
+	sub, ok := m.subs[dl.SubscriptionId]
+	if !ok {
+		return fmt.Errorf("webhook with id=%d not found", dl.SubscriptionId)
+	}
+
+	var ev struct {
+		Id int `json:"id"`
+	}
+	json.Unmarshal(dl.Payload, &ev)
+	if err := m.enqueue(sub, event{ev.Id, dl.EventType, dl.Payload}); err != nil {
+		return fmt.Errorf("retrying dead letter with id=%d: %w", id, err)
+	}
+	delete(m.dead, id)
+	return nil
+}
+
+// HandleEvent queues ev for delivery to all interested subscriptions. It
+// doesn't block, so it can be passed to GroceryItemStore.Subscribe; events
+// that don't fit in the queue of a subscription go to the dead-letter queue.
+func (m *Manager) HandleEvent(ev groceryItemStore.Event) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	for _, sub := range m.subs {
+		if !sub.wants(ev.Type) {
+			continue
+		}
+		if err := m.enqueue(sub, event{ev.Id, ev.Type, payload}); err != nil {
+			m.deadLetter(sub, ev.Type, payload, err)
+		}
+	}
+}
+
+// enqueue queues ev for delivery to sub. The caller must hold the lock.
+func (m *Manager) enqueue(sub Subscription, ev event) error {
+	queue, ok := m.queues[sub.Id]
+	if !ok {
+		return fmt.Errorf("webhooks are shut down: %w", context.Canceled)
+	}
+	m.pending.Add(1)
+	select {
+	case queue <- ev:
+		return nil
+	default:
+		m.pending.Done()
+		return ErrBacklogFull
+	}
+}
+
+// work delivers the events queued for sub, one at a time, until the queue is
+// closed. Once the Manager is closed, the remaining events go to the
+// dead-letter queue without being delivered; those of a deleted subscription
+// are dropped.
+func (m *Manager) work(sub Subscription, queue <-chan event) {
+	defer m.wg.Done()
+	for ev := range queue {
+		switch {
+		case !m.subscribed(sub.Id):
+		case m.ctx.Err() != nil:
+			m.kill(sub, ev.typ, ev.payload, m.ctx.Err())
+		default:
+			m.deliver(sub, ev.id, ev.typ, ev.payload)
+		}
+		m.pending.Done()
+	}
+}
+
+func (m *Manager) subscribed(id int) bool {
+	m.Lock()
+	defer m.Unlock()
+
+	_, ok := m.subs[id]
+	return ok
+}
+
+// deliver POSTs payload to sub, retrying with exponential backoff, and puts it
+// in the dead-letter queue if all attempts fail.
+func (m *Manager) deliver(sub Subscription, eventId int, eventType string, payload []byte) {
+	backoff := m.opts.InitialBackoff
+	var lastErr error
+	for attempt := 1; attempt <= m.opts.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-m.ctx.Done():
+				m.kill(sub, eventType, payload, m.ctx.Err())
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		status, err := m.post(sub, eventType, payload)
+		d := Delivery{
+			SubscriptionId: sub.Id,
+			EventId:        eventId,
+			EventType:      eventType,
+			Attempt:        attempt,
+			Time:           time.Now(),
+			StatusCode:     status,
+		}
+		if err != nil {
+			d.Error = err.Error()
+		}
+		m.logDelivery(d)
+		if err == nil {
+			return
+		}
+		lastErr = err
+	}
+	m.kill(sub, eventType, payload, lastErr)
+}
+
+// post makes a single delivery attempt and returns the response status.
+func (m *Manager) post(sub Subscription, eventType string, payload []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", eventType)
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, payload))
+
+	resp, err := m.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+func (m *Manager) logDelivery(d Delivery) {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.subs[d.SubscriptionId]; !ok {
+		return
+	}
+	m.nextDelivery++
+	d.Id = m.nextDelivery
+	log := append(m.deliveries[d.SubscriptionId], d)
+	if len(log) > m.opts.MaxLogSize {
+		log = log[len(log)-m.opts.MaxLogSize:]
+	}
+	m.deliveries[d.SubscriptionId] = log
+}
+
+// kill puts a failed delivery in the dead-letter queue.
+func (m *Manager) kill(sub Subscription, eventType string, payload []byte, err error) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.deadLetter(sub, eventType, payload, err)
+}
+
+// deadLetter is kill for callers holding the lock. If the queue is full, the
+// oldest dead letters make room.
+func (m *Manager) deadLetter(sub Subscription, eventType string, payload []byte, err error) {
+	for len(m.dead) > 0 && len(m.dead) >= m.opts.MaxDeadLetters {
+		for ; ; m.oldestDeadId++ {
+			if _, ok := m.dead[m.oldestDeadId]; ok {
+				break
+			}
+		}
+		delete(m.dead, m.oldestDeadId)
+	}
+
+	m.nextDeadId++
+	dl := DeadLetter{
+		Id:             m.nextDeadId,
+		SubscriptionId: sub.Id,
+		EventType:      eventType,
+		Payload:        payload,
+		Time:           time.Now(),
+	}
+	if err != nil {
+		dl.Error = err.Error()
+	}
+	m.dead[dl.Id] = dl
+}
+
+// Sign returns the value of SignatureHeader for payload signed with secret.
+// Receivers verify a delivery by computing the same value and comparing it
+// with hmac.Equal.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
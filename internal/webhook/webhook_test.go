@@ -0,0 +1,181 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/validate"
+)
+
+func testOptions() Options {
+	return Options{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxLogSize:     10,
+		Backlog:        10,
+		MaxDeadLetters: 2,
+		Client:         http.DefaultClient,
+	}
+}
+
+func TestDeliverSigned(t *testing.T) {
+	received := make(chan bool, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		received <- req.Header.Get(SignatureHeader) == Sign("s3cret", body) &&
+			req.Header.Get("X-Webhook-Event") == groceryItemStore.FoodCreated
+	}))
+	defer ts.Close()
+
+	m := New(testOptions())
+	id, err := m.Subscribe(ts.URL, "s3cret", []string{groceryItemStore.FoodCreated})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gis := groceryItemStore.New()
+	gis.Subscribe(m.HandleEvent)
+	gis.CreateLocation(context.Background(), "Fridge", groceryItemStore.KindFridge) // not subscribed to
+	gis.CreateFood(context.Background(), "Milk", "", nil, time.Time{}, groceryItemStore.Nutrition{})
+	m.pending.Wait()
+	m.Close()
+
+	if ok := <-received; !ok {
+		t.Error("got bad signature or event type")
+	}
+	log, err := m.GetDeliveries(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 1 || log[0].StatusCode != http.StatusOK || log[0].Error != "" {
+		t.Errorf("got delivery log %+v, want one successful delivery", log)
+	}
+}
+
+func TestRetryAndDeadLetter(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	m := New(testOptions())
+	id, _ := m.Subscribe(ts.URL, "", nil)
+	m.HandleEvent(groceryItemStore.Event{Id: 7, Type: groceryItemStore.FoodDeleted})
+	m.pending.Wait()
+
+	if calls := atomic.LoadInt32(&calls); calls != 3 {
+		t.Errorf("got %d calls, want 3", calls)
+	}
+	log, _ := m.GetDeliveries(id)
+	if len(log) != 3 || log[2].Attempt != 3 || log[2].StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got delivery log %+v, want three failed attempts", log)
+	}
+	dead := m.GetDeadLetters()
+	if len(dead) != 1 || dead[0].SubscriptionId != id {
+		t.Fatalf("got dead letters %+v, want one", dead)
+	}
+
+	if err := m.RetryDeadLetter(dead[0].Id); err != nil {
+		t.Fatal(err)
+	}
+	m.pending.Wait()
+	if calls := atomic.LoadInt32(&calls); calls != 6 {
+		t.Errorf("got %d calls after retry, want 6", calls)
+	}
+	if dead := m.GetDeadLetters(); len(dead) != 1 {
+		t.Errorf("got %d dead letters after failed retry, want 1", len(dead))
+	}
+	m.Close()
+}
+
+func TestBacklog(t *testing.T) {
+	received := make(chan int)
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var ev groceryItemStore.Event
+		json.NewDecoder(req.Body).Decode(&ev)
+		received <- ev.Id
+		<-release
+	}))
+	defer ts.Close()
+
+	opts := testOptions()
+	opts.Backlog = 1
+	m := New(opts)
+	defer m.Close()
+	id, _ := m.Subscribe(ts.URL, "", nil)
+
+	// The first event is being delivered, the second waits in the queue and
+	// the third doesn't fit.
+	m.HandleEvent(groceryItemStore.Event{Id: 1, Type: groceryItemStore.FoodCreated})
+	if got := <-received; got != 1 {
+		t.Fatalf("got event %d, want 1", got)
+	}
+	m.HandleEvent(groceryItemStore.Event{Id: 2, Type: groceryItemStore.FoodCreated})
+	m.HandleEvent(groceryItemStore.Event{Id: 3, Type: groceryItemStore.FoodCreated})
+	dead := m.GetDeadLetters()
+	if len(dead) != 1 || dead[0].Error != ErrBacklogFull.Error() {
+		t.Fatalf("got dead letters %+v, want one with a full backlog", dead)
+	}
+
+	close(release)
+	if got := <-received; got != 2 {
+		t.Errorf("got event %d, want 2", got)
+	}
+	if err := m.RetryDeadLetter(dead[0].Id); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-received; got != 3 {
+		t.Errorf("got event %d, want 3", got)
+	}
+	m.pending.Wait()
+	if log, _ := m.GetDeliveries(id); len(log) != 3 || log[0].EventId != 1 || log[2].EventId != 3 {
+		t.Errorf("got delivery log %+v, want events 1 to 3 in order", log)
+	}
+}
+
+func TestSubscribeValidates(t *testing.T) {
+	m := New(testOptions())
+	defer m.Close()
+
+	_, err := m.Subscribe("localhost/hook", "", []string{groceryItemStore.FoodCreated, "food.eaten"})
+	var errs validate.Errors
+	if !errors.As(err, &errs) || len(errs) != 2 || errs[0].Field != "url" || errs[1].Field != "events[1]" {
+		t.Errorf("got %v, want errors for the url and the second event", err)
+	}
+	if subs := m.GetAllSubscriptions(); len(subs) != 0 {
+		t.Errorf("got subscriptions %+v", subs)
+	}
+}
+
+func TestDeadLetterLimit(t *testing.T) {
+	m := New(testOptions())
+	defer m.Close()
+	sub := Subscription{Id: 1}
+
+	for i := 1; i <= 4; i++ {
+		m.kill(sub, groceryItemStore.FoodCreated, []byte(strconv.Itoa(i)), nil)
+	}
+	m.DeleteDeadLetter(3)
+	for i := 5; i <= 6; i++ {
+		m.kill(sub, groceryItemStore.FoodCreated, []byte(strconv.Itoa(i)), nil)
+	}
+
+	dead := m.GetDeadLetters()
+	sort.Slice(dead, func(i, j int) bool { return dead[i].Id < dead[j].Id })
+	if len(dead) != 2 || dead[0].Id != 5 || dead[1].Id != 6 {
+		t.Errorf("got dead letters %+v, want the two newest", dead)
+	}
+}
@@ -0,0 +1,153 @@
+// Handlers for storage locations and for moving food items between them.
+
+package main
+
+import (
+	"net/http"
+
+	"github.com/diorchen/rest-server/internal/middleware"
+)
+
+// requestLocation is the payload for creating or updating a location.
+type requestLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+func (fs *foodServer) createLocationHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling location creation")
+
+	var rl requestLocation
+	if !decodeJSON(w, req, &rl) {
+		return
+	}
+
+	id, err := fs.groceryItemStore.CreateLocation(req.Context(), rl.Name, rl.Kind)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	renderJSON(w, responseId{Id: id})
+}
+
+func (fs *foodServer) getAllLocationsHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling get all locations")
+	if fs.notModified(w, req) {
+		return
+	}
+	locs, err := fs.groceryItemStore.GetAllLocations(req.Context())
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	renderJSON(w, locs)
+}
+
+func (fs *foodServer) getLocationHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling get location")
+	id, ok := pathInt(w, req, "id")
+	if !ok {
+		return
+	}
+
+	modified := fs.groceryItemStore.LastModified()
+	loc, err := fs.groceryItemStore.GetLocation(req.Context(), id)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if notModifiedSince(w, req, modified) {
+		return
+	}
+	renderJSON(w, loc)
+}
+
+func (fs *foodServer) updateLocationHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling location update")
+	id, ok := pathInt(w, req, "id")
+	if !ok {
+		return
+	}
+
+	var rl requestLocation
+	if !decodeJSON(w, req, &rl) {
+		return
+	}
+	if err := fs.groceryItemStore.UpdateLocation(req.Context(), id, rl.Name, rl.Kind); err != nil {
+		renderError(w, req, err)
+		return
+	}
+}
+
+func (fs *foodServer) deleteLocationHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling deletion of location")
+	id, ok := pathInt(w, req, "id")
+	if !ok {
+		return
+	}
+	if err := fs.groceryItemStore.DeleteLocation(req.Context(), id); err != nil {
+		renderError(w, req, err)
+		return
+	}
+}
+
+func (fs *foodServer) locationFoodHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling food items by location")
+	id, ok := pathInt(w, req, "id")
+	if !ok {
+		return
+	}
+
+	modified := fs.groceryItemStore.LastModified()
+	food, err := fs.groceryItemStore.GetFoodByLocation(req.Context(), id)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if notModifiedSince(w, req, modified) {
+		return
+	}
+	render(w, req, food)
+}
+
+// requestMove is the payload for moving a food item to another location.
+type requestMove struct {
+	Location int `json:"location"`
+}
+
+func (fs *foodServer) moveFoodHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling food move")
+
+	id, ok := pathInt(w, req, "id")
+	if !ok {
+		return
+	}
+
+	var rm requestMove
+	if !decodeJSON(w, req, &rm) {
+		return
+	}
+	if err := fs.groceryItemStore.MoveFood(req.Context(), id, rm.Location); err != nil {
+		renderError(w, req, err)
+		return
+	}
+}
+
+func (fs *foodServer) foodHistoryHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling food history")
+	id, ok := pathInt(w, req, "id")
+	if !ok {
+		return
+	}
+
+	modified := fs.groceryItemStore.LastModified()
+	history, err := fs.groceryItemStore.GetFoodHistory(req.Context(), id)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if notModifiedSince(w, req, modified) {
+		return
+	}
+	renderJSON(w, history)
+}
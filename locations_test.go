@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/problem"
+)
+
+func TestLocationHandlers(t *testing.T) {
+	server, h := newTestServer(t)
+	milk := server.groceryItemStore.CreateFood(context.Background(), "Milk", "", nil, label, groceryItemStore.Nutrition{})
+
+	rr := serve(h, request{method: "POST", path: "/v1/locations/", body: `{"name": "Fridge", "kind": "fridge"}`, auth: true})
+	var created responseId
+	decode(t, rr, &created)
+	wantProblem(t, serve(h, request{method: "POST", path: "/v1/locations/", body: `{"name": "Cellar", "kind": "cellar"}`, auth: true}),
+		http.StatusBadRequest, problem.CodeValidation)
+
+	var loc groceryItemStore.Location
+	decode(t, serve(h, request{method: "GET", path: fmt.Sprintf("/v1/locations/%d/", created.Id)}), &loc)
+	if loc.Name != "Fridge" || loc.Kind != groceryItemStore.KindFridge {
+		t.Errorf("got %+v, want the fridge", loc)
+	}
+	wantProblem(t, serve(h, request{method: "GET", path: "/v1/locations/99/"}), http.StatusNotFound, problem.CodeNotFound)
+	wantProblem(t, serve(h, request{method: "PUT", path: "/v1/locations/99/", body: `{"name": "Pantry", "kind": "pantry"}`, auth: true}),
+		http.StatusNotFound, problem.CodeNotFound)
+	wantProblem(t, serve(h, request{method: "DELETE", path: "/v1/locations/99/", auth: true}), http.StatusNotFound, problem.CodeNotFound)
+	wantProblem(t, serve(h, request{method: "POST", path: "/v1/food/99/move/", body: fmt.Sprintf(`{"location": %d}`, created.Id), auth: true}),
+		http.StatusNotFound, problem.CodeNotFound)
+
+	move := fmt.Sprintf(`{"location": %d}`, created.Id)
+	if rr := serve(h, request{method: "POST", path: fmt.Sprintf("/v1/food/%d/move/", milk), body: move, auth: true}); rr.Code != http.StatusOK {
+		t.Fatalf("move: got %d %s", rr.Code, rr.Body.String())
+	}
+
+	var foods []groceryItemStore.FoodItem
+	decode(t, serve(h, request{method: "GET", path: fmt.Sprintf("/v1/locations/%d/food/", created.Id)}), &foods)
+	if len(foods) != 1 || foods[0].Id != milk {
+		t.Errorf("got %+v at the fridge, want the milk", foods)
+	}
+	var history []groceryItemStore.HistoryEntry
+	decode(t, serve(h, request{method: "GET", path: fmt.Sprintf("/v1/food/%d/history/", milk)}), &history)
+	if len(history) != 1 || history[0].To != created.Id {
+		t.Errorf("got history %+v, want one move to the fridge", history)
+	}
+
+	wantProblem(t, serve(h, request{method: "DELETE", path: fmt.Sprintf("/v1/locations/%d/", created.Id), auth: true}),
+		http.StatusConflict, problem.CodeConflict)
+}
@@ -0,0 +1,46 @@
+// Handlers for the expiration notification preferences and in-app inbox of the
+// authenticated user.
+
+package main
+
+import (
+	"net/http"
+
+	"github.com/diorchen/rest-server/internal/middleware"
+	"github.com/diorchen/rest-server/internal/notify"
+	"github.com/diorchen/rest-server/internal/problem"
+)
+
+// requestUser returns the user authenticated by middleware.BasicAuth.
+func requestUser(req *http.Request) string {
+	user, _ := req.Context().Value(middleware.UserContextKey).(string)
+	return user
+}
+
+func (fs *foodServer) getPreferencesHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling get notification preferences")
+	renderJSON(w, fs.notifier.GetPreferences(requestUser(req)))
+}
+
+func (fs *foodServer) setPreferencesHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling notification preferences update")
+
+	var prefs notify.Preferences
+	if !decodeJSON(w, req, &prefs) {
+		return
+	}
+	if err := fs.notifier.SetPreferences(requestUser(req), prefs); err != nil {
+		renderProblem(w, req, http.StatusBadRequest, problem.CodeValidation, err.Error())
+		return
+	}
+}
+
+func (fs *foodServer) getInboxHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling get inbox")
+	renderJSON(w, fs.inbox.Get(requestUser(req)))
+}
+
+func (fs *foodServer) clearInboxHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling clearing of inbox")
+	fs.inbox.Clear(requestUser(req))
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/diorchen/rest-server/internal/notify"
+	"github.com/diorchen/rest-server/internal/problem"
+)
+
+func TestNotificationHandlers(t *testing.T) {
+	_, h := newTestServer(t)
+
+	prefs := `{"leadHours": [48], "email": "joe@example.com"}`
+	if rr := serve(h, request{method: "PUT", path: "/v1/notifications/preferences/", body: prefs, auth: true}); rr.Code != http.StatusOK {
+		t.Fatalf("set preferences: got %d %s", rr.Code, rr.Body.String())
+	}
+	wantProblem(t, serve(h, request{method: "PUT", path: "/v1/notifications/preferences/", body: `{"leadHours": [0]}`, auth: true}),
+		http.StatusBadRequest, problem.CodeValidation)
+
+	var got notify.Preferences
+	decode(t, serve(h, request{method: "GET", path: "/v1/notifications/preferences/", auth: true}), &got)
+	if len(got.LeadHours) != 1 || got.LeadHours[0] != 48 || got.Email != "joe@example.com" {
+		t.Errorf("got %+v", got)
+	}
+
+	var inbox []notify.Notification
+	decode(t, serve(h, request{method: "GET", path: "/v1/inbox/", auth: true}), &inbox)
+	if len(inbox) != 0 {
+		t.Errorf("got inbox %+v, want it empty", inbox)
+	}
+	wantProblem(t, serve(h, request{method: "GET", path: "/v1/inbox/"}), http.StatusUnauthorized, problem.CodeUnauthorized)
+}
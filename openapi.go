@@ -0,0 +1,125 @@
+// Descriptions of the routes for the generated OpenAPI document.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/diorchen/rest-server/internal/codec"
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/notify"
+	"github.com/diorchen/rest-server/internal/openapi"
+	"github.com/diorchen/rest-server/internal/webhook"
+)
+
+var apiInfo = openapi.Info{
+	Title:       "Grocery Item Store API",
+	Description: "Keeps inventory of groceries bought with expiration dates and other related information.",
+	Version:     "1.0.0",
+}
+
+// graphqlRequest is the body of a GraphQL POST request.
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// apiOperations describes the routes registered in main. Generating the
+// document fails if an entry doesn't match a registered route.
+var apiOperations = withVersions(openapi.Operations{
+	"GET /events": {
+		Summary: "Stream store events as Server-Sent Events",
+		Params: []openapi.Param{
+			{Name: "Last-Event-ID", In: "header", Description: "id of the last event seen, to resume after it"},
+			{Name: "lastEventId", In: "query", Description: "like Last-Event-ID, for clients that can't set headers"},
+		},
+		ContentType: "text/event-stream",
+	},
+	"GET /ws": {Summary: "Upgrade to a WebSocket streaming diffs of filtered views"},
+	"GET /graphql": {
+		Summary: "Run a GraphQL query",
+		Params: []openapi.Param{
+			{Name: "query", In: "query", Description: "the GraphQL document"},
+			{Name: "operationName", In: "query"},
+			{Name: "variables", In: "query", Description: "JSON object of variables"},
+		},
+		Response: map[string]interface{}{},
+	},
+	"POST /graphql": {Summary: "Run a GraphQL query or mutation; mutations need basic auth", Request: graphqlRequest{}, Response: map[string]interface{}{}},
+
+	"GET /metrics": {Summary: "Metrics in the Prometheus exposition format", ContentType: "text/plain"},
+	"GET /healthz": {Summary: "Liveness probe", Response: responseCheck{}},
+	"GET /readyz":  {Summary: "Readiness probe; 503 if the store or the TLS certificate isn't ready", Response: responseReady{}},
+	"GET /version": {Summary: "Version and build information", Response: responseVersion{}},
+
+	"GET /openapi.json": {Summary: "This OpenAPI document", Response: map[string]interface{}{}},
+	"GET /docs/":        {Summary: "Documentation of the API", ContentType: "text/html"},
+}, resourceOperations)
+
+// resourceOperations describes the routes registered by registerAPIRoutes,
+// without a version prefix.
+var resourceOperations = openapi.Operations{
+	"POST /food/":                    {Summary: "Create a food item", Auth: true, Request: requestFood{}, Response: responseId{}, MediaTypes: codec.ContentTypes()},
+	"GET /food/":                     {Summary: "List all food items", Response: []groceryItemStore.FoodItem{}, MediaTypes: codec.ContentTypes()},
+	"DELETE /food/":                  {Summary: "Delete all food items"},
+	"GET /food/{id}/":                {Summary: "Get a food item", Response: groceryItemStore.FoodItem{}, MediaTypes: codec.ContentTypes()},
+	"DELETE /food/{id}/":             {Summary: "Delete a food item"},
+	"GET /ing/{ing}/":                {Summary: "List food items containing an ingredient", Response: []groceryItemStore.FoodItem{}, MediaTypes: codec.ContentTypes()},
+	"GET /exp/{year}/{month}/{day}/": {Summary: "List food items expiring on a date", Response: []groceryItemStore.FoodItem{}, MediaTypes: codec.ContentTypes()},
+	"POST /food/{id}/move/":          {Summary: "Move a food item to another location", Auth: true, Request: requestMove{}},
+	"GET /food/{id}/history/":        {Summary: "List the moves of a food item", Response: []groceryItemStore.HistoryEntry{}},
+	"POST /food/{id}/open/":          {Summary: "Mark a food item as opened", Auth: true},
+	"GET /rules/":                    {Summary: "List shelf-life rules", Response: []groceryItemStore.ShelfLifeRule{}},
+	"PUT /rules/{category}/":         {Summary: "Set the shelf-life rule of a category", Auth: true, Request: requestRule{}},
+	"DELETE /rules/{category}/":      {Summary: "Delete the shelf-life rule of a category", Auth: true},
+
+	"GET /notifications/preferences/": {Summary: "Get your notification preferences", Auth: true, Response: notify.Preferences{}},
+	"PUT /notifications/preferences/": {Summary: "Set your notification preferences", Auth: true, Request: notify.Preferences{}},
+	"GET /inbox/":                     {Summary: "List your expiration notifications", Auth: true, Response: []notify.Notification{}},
+	"DELETE /inbox/":                  {Summary: "Clear your expiration notifications", Auth: true},
+
+	"POST /webhooks/":                 {Summary: "Subscribe a webhook", Auth: true, Request: requestWebhook{}, Response: responseWebhook{}},
+	"GET /webhooks/":                  {Summary: "List webhook subscriptions", Auth: true, Response: []webhook.Subscription{}},
+	"GET /webhooks/{id}/":             {Summary: "Get a webhook subscription", Auth: true, Response: webhook.Subscription{}},
+	"DELETE /webhooks/{id}/":          {Summary: "Delete a webhook subscription", Auth: true},
+	"GET /webhooks/{id}/deliveries/":  {Summary: "List recent deliveries of a webhook", Auth: true, Response: []webhook.Delivery{}},
+	"GET /webhooks/dead/":             {Summary: "List undeliverable events", Auth: true, Response: []webhook.DeadLetter{}},
+	"POST /webhooks/dead/{id}/retry/": {Summary: "Retry delivering an undeliverable event", Auth: true},
+	"DELETE /webhooks/dead/{id}/":     {Summary: "Discard an undeliverable event", Auth: true},
+
+	"POST /locations/":          {Summary: "Create a location", Auth: true, Request: requestLocation{}, Response: responseId{}},
+	"GET /locations/":           {Summary: "List locations", Response: []groceryItemStore.Location{}},
+	"GET /locations/{id}/":      {Summary: "Get a location", Response: groceryItemStore.Location{}},
+	"PUT /locations/{id}/":      {Summary: "Update a location", Auth: true, Request: requestLocation{}},
+	"DELETE /locations/{id}/":   {Summary: "Delete an empty location", Auth: true},
+	"GET /locations/{id}/food/": {Summary: "List food items stored at a location", Response: []groceryItemStore.FoodItem{}, MediaTypes: codec.ContentTypes()},
+
+	"GET /export": {Summary: "Export the whole inventory as a versioned archive", Auth: true, Response: archive{}},
+	"POST /import": {
+		Summary: "Import an archive made by /export",
+		Auth:    true,
+		Params: []openapi.Param{
+			{Name: "mode", In: "query", Description: "merge (default) adds to the inventory, replace deletes it first"},
+			{Name: "dryRun", In: "query", Description: "true to only report what the import would do"},
+		},
+		Request:  archive{},
+		Response: responseImport{},
+	},
+}
+
+// withVersions adds the resource operations of every API version to ops, under
+// the version's prefix and with the version's representation of responses.
+func withVersions(ops openapi.Operations, resources openapi.Operations) openapi.Operations {
+	for _, v := range apiVersions {
+		for key, op := range resources {
+			method, path, _ := strings.Cut(key, " ")
+			if op.Response != nil {
+				op.Response = represent(v.Representation, op.Response)
+			}
+			op.Deprecated = !v.Deprecated.IsZero()
+			ops[method+" "+v.Prefix+path] = op
+		}
+	}
+	return ops
+}
@@ -0,0 +1,30 @@
+// Typed access to the variables in the paths of routes.
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/diorchen/rest-server/internal/problem"
+)
+
+// pathInt returns the path variable name of req as an int. The routes only
+// match digits for numeric variables, so this fails only for values too large
+// for an int, which can't be IDs of anything: a not found problem is written to
+// w and false is returned.
+func pathInt(w http.ResponseWriter, req *http.Request, name string) (int, bool) {
+	n, err := strconv.Atoi(mux.Vars(req)[name])
+	if err != nil {
+		renderProblem(w, req, http.StatusNotFound, problem.CodeNotFound, "expect numeric "+name)
+		return 0, false
+	}
+	return n, true
+}
+
+// pathString returns the path variable name of req.
+func pathString(req *http.Request, name string) string {
+	return mux.Vars(req)[name]
+}
@@ -0,0 +1,146 @@
+// Routing of requests to the handlers of the server.
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/diorchen/rest-server/internal/compress"
+	"github.com/diorchen/rest-server/internal/config"
+	"github.com/diorchen/rest-server/internal/cors"
+	"github.com/diorchen/rest-server/internal/gql"
+	"github.com/diorchen/rest-server/internal/grpcserver"
+	"github.com/diorchen/rest-server/internal/middleware"
+	"github.com/diorchen/rest-server/internal/openapi"
+	"github.com/diorchen/rest-server/internal/problem"
+	"github.com/diorchen/rest-server/internal/ratelimit"
+	"github.com/diorchen/rest-server/internal/tracing"
+)
+
+// newHandler wraps the router of newRouter in the middleware of the server,
+// configured by cfg, and logs requests to logger.
+func newHandler(server *foodServer, cfg config.Config, logger *slog.Logger) (http.Handler, error) {
+	server.limits = cfg.Limits
+	server.checkOrigin = cors.CheckOrigin(cfg.CORS)
+	router, err := newRouter(server)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set up panic recovery and record the matched routes for the request log.
+	// Requests are rate limited, and bounded in size and duration.
+	router.Use(middleware.Route)
+	router.Use(middleware.PanicRecovery)
+	limits := ratelimit.New(cfg.RateLimit)
+	router.Use(limits.Middleware)
+	router.Use(middleware.BodyLimit(cfg.Limits.BodyBytes))
+	router.Use(middleware.Timeout(time.Duration(cfg.Timeouts.Request)))
+
+	// gRPC shares the TLS listener: HTTP/2 requests with a gRPC content type go
+	// to the gRPC server, everything else to the router, behind CORS handling
+	// and response compression. Both are traced, logged, measured and rate
+	// limited. gRPC calls carry their own deadlines and may stream for long, so
+	// the server's timeouts don't apply to them.
+	grpcServer := middleware.Streaming(grpcserver.New(server.groceryItemStore, server.feed, limits))
+	api := compress.Middleware(cors.New(cfg.CORS, router))
+	return tracing.Middleware(middleware.Logging(logger)(server.metrics.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.ProtoMajor == 2 && strings.HasPrefix(req.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, req)
+			return
+		}
+		api.ServeHTTP(w, req)
+	})))), nil
+}
+
+// newRouter routes all the endpoints of the server to their handlers. Handlers
+// read their path variables with pathInt and pathString; the routes make sure
+// numeric variables are digits.
+func newRouter(server *foodServer) (*mux.Router, error) {
+	schema, err := gql.NewSchema(server.groceryItemStore, server.feed)
+	if err != nil {
+		return nil, err
+	}
+
+	router := mux.NewRouter()
+	router.StrictSlash(true)
+
+	router.Handle("/events", middleware.Streaming(http.HandlerFunc(server.eventsHandler))).Methods("GET")
+	router.Handle("/ws", middleware.Streaming(http.HandlerFunc(server.wsHandler))).Methods("GET")
+	router.Handle("/graphql", middleware.Streaming(middleware.OptionalBasicAuth(&gql.Handler{Schema: schema, Subscriptions: true}))).
+		Methods("POST").MatcherFunc(acceptsEventStream)
+	router.Handle("/graphql", middleware.OptionalBasicAuth(&gql.Handler{Schema: schema})).Methods("GET", "POST")
+	router.Handle("/metrics", server.metrics.Handler()).Methods("GET")
+	router.Handle("/healthz", middleware.NoAccessLog(http.HandlerFunc(server.healthHandler))).Methods("GET")
+	router.Handle("/readyz", middleware.NoAccessLog(http.HandlerFunc(server.readyHandler))).Methods("GET")
+	router.Handle("/version", middleware.NoAccessLog(http.HandlerFunc(server.versionHandler))).Methods("GET")
+
+	// The REST resources are served once per API version, under its prefix.
+	for _, v := range apiVersions {
+		registerAPIRoutes(v.subrouter(router), server)
+	}
+
+	spec := &openapi.Handler{Router: router, Info: apiInfo, Operations: apiOperations}
+	router.Handle("/openapi.json", spec).Methods("GET")
+	router.Handle("/docs/", openapi.DocsHandler("/openapi.json")).Methods("GET")
+
+	router.NotFoundHandler = problem.Handler(http.StatusNotFound, problem.CodeNotFound)
+	router.MethodNotAllowedHandler = problem.Handler(http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed)
+
+	if err := spec.Generate(); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	return router, nil
+}
+
+// registerAPIRoutes registers the versioned REST resources on router.
+func registerAPIRoutes(router *mux.Router, server *foodServer) {
+	router.Handle("/food/", middleware.BasicAuth(http.HandlerFunc(server.createFoodHandler))).Methods("POST")
+	router.HandleFunc("/food/", server.getAllFoodHandler).Methods("GET")
+	router.HandleFunc("/food/", server.deleteAllFoodHandler).Methods("DELETE")
+	router.HandleFunc("/food/{id:[0-9]+}/", server.deleteFoodHandler).Methods("DELETE")
+	router.HandleFunc("/food/{id:[0-9]+}/", server.getFoodHandler).Methods("GET")
+	router.HandleFunc("/ing/{ing}/", server.ingHandler).Methods("GET")
+	router.HandleFunc("/exp/{year:[0-9]+}/{month:[0-9]+}/{day:[0-9]+}/", server.expHandler).Methods("GET")
+	router.Handle("/food/{id:[0-9]+}/move/", middleware.BasicAuth(http.HandlerFunc(server.moveFoodHandler))).Methods("POST")
+	router.HandleFunc("/food/{id:[0-9]+}/history/", server.foodHistoryHandler).Methods("GET")
+	router.Handle("/food/{id:[0-9]+}/open/", middleware.BasicAuth(http.HandlerFunc(server.openFoodHandler))).Methods("POST")
+
+	router.HandleFunc("/rules/", server.getAllRulesHandler).Methods("GET")
+	router.Handle("/rules/{category}/", middleware.BasicAuth(http.HandlerFunc(server.setRuleHandler))).Methods("PUT")
+	router.Handle("/rules/{category}/", middleware.BasicAuth(http.HandlerFunc(server.deleteRuleHandler))).Methods("DELETE")
+
+	router.Handle("/notifications/preferences/", middleware.BasicAuth(http.HandlerFunc(server.getPreferencesHandler))).Methods("GET")
+	router.Handle("/notifications/preferences/", middleware.BasicAuth(http.HandlerFunc(server.setPreferencesHandler))).Methods("PUT")
+	router.Handle("/inbox/", middleware.BasicAuth(http.HandlerFunc(server.getInboxHandler))).Methods("GET")
+	router.Handle("/inbox/", middleware.BasicAuth(http.HandlerFunc(server.clearInboxHandler))).Methods("DELETE")
+
+	router.Handle("/webhooks/", middleware.BasicAuth(http.HandlerFunc(server.createWebhookHandler))).Methods("POST")
+	router.Handle("/webhooks/", middleware.BasicAuth(http.HandlerFunc(server.getAllWebhooksHandler))).Methods("GET")
+	router.Handle("/webhooks/{id:[0-9]+}/", middleware.BasicAuth(http.HandlerFunc(server.getWebhookHandler))).Methods("GET")
+	router.Handle("/webhooks/{id:[0-9]+}/", middleware.BasicAuth(http.HandlerFunc(server.deleteWebhookHandler))).Methods("DELETE")
+	router.Handle("/webhooks/{id:[0-9]+}/deliveries/", middleware.BasicAuth(http.HandlerFunc(server.webhookDeliveriesHandler))).Methods("GET")
+	router.Handle("/webhooks/dead/", middleware.BasicAuth(http.HandlerFunc(server.getDeadLettersHandler))).Methods("GET")
+	router.Handle("/webhooks/dead/{id:[0-9]+}/retry/", middleware.BasicAuth(http.HandlerFunc(server.retryDeadLetterHandler))).Methods("POST")
+	router.Handle("/webhooks/dead/{id:[0-9]+}/", middleware.BasicAuth(http.HandlerFunc(server.deleteDeadLetterHandler))).Methods("DELETE")
+
+	router.Handle("/locations/", middleware.BasicAuth(http.HandlerFunc(server.createLocationHandler))).Methods("POST")
+	router.HandleFunc("/locations/", server.getAllLocationsHandler).Methods("GET")
+	router.HandleFunc("/locations/{id:[0-9]+}/", server.getLocationHandler).Methods("GET")
+	router.Handle("/locations/{id:[0-9]+}/", middleware.BasicAuth(http.HandlerFunc(server.updateLocationHandler))).Methods("PUT")
+	router.Handle("/locations/{id:[0-9]+}/", middleware.BasicAuth(http.HandlerFunc(server.deleteLocationHandler))).Methods("DELETE")
+	router.HandleFunc("/locations/{id:[0-9]+}/food/", server.locationFoodHandler).Methods("GET")
+
+	router.Handle("/export", middleware.BasicAuth(http.HandlerFunc(server.exportHandler))).Methods("GET")
+	router.Handle("/import", middleware.MaxBytes(server.limits.ImportBodyBytes, middleware.BasicAuth(http.HandlerFunc(server.importHandler)))).Methods("POST")
+}
+
+// acceptsEventStream matches the requests for GraphQL subscriptions.
+func acceptsEventStream(req *http.Request, _ *mux.RouteMatch) bool {
+	return strings.Contains(req.Header.Get("Accept"), "text/event-stream")
+}
@@ -0,0 +1,69 @@
+// Handlers for shelf-life rules and for opening food items.
+
+package main
+
+import (
+	"net/http"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/middleware"
+)
+
+func (fs *foodServer) getAllRulesHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling get all shelf-life rules")
+	if fs.notModified(w, req) {
+		return
+	}
+	rules, err := fs.groceryItemStore.GetShelfLifeRules(req.Context())
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	renderJSON(w, rules)
+}
+
+// requestRule is the payload for setting the shelf-life rule of a category.
+type requestRule struct {
+	OpenedDays int `json:"openedDays"`
+	FrozenDays int `json:"frozenDays"`
+}
+
+func (fs *foodServer) setRuleHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling shelf-life rule update")
+
+	var rr requestRule
+	if !decodeJSON(w, req, &rr) {
+		return
+	}
+
+	rule := groceryItemStore.ShelfLifeRule{
+		Category:   pathString(req, "category"),
+		OpenedDays: rr.OpenedDays,
+		FrozenDays: rr.FrozenDays,
+	}
+	if err := fs.groceryItemStore.SetShelfLifeRule(req.Context(), rule); err != nil {
+		renderError(w, req, err)
+		return
+	}
+}
+
+func (fs *foodServer) deleteRuleHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling deletion of shelf-life rule")
+	if err := fs.groceryItemStore.DeleteShelfLifeRule(req.Context(), pathString(req, "category")); err != nil {
+		renderError(w, req, err)
+		return
+	}
+}
+
+func (fs *foodServer) openFoodHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling opening of food item")
+	id, ok := pathInt(w, req, "id")
+	if !ok {
+		return
+	}
+
+	if err := fs.groceryItemStore.OpenFood(req.Context(), id); err != nil {
+		renderError(w, req, err)
+		return
+	}
+}
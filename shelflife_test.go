@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/problem"
+)
+
+func TestShelfLifeHandlers(t *testing.T) {
+	server, h := newTestServer(t)
+	id, _ := server.groceryItemStore.AddFood(context.Background(), groceryItemStore.FoodItem{Name: "Milk", Category: "milk", Expiration: label})
+
+	if rr := serve(h, request{method: "PUT", path: "/v1/rules/milk/", body: `{"openedDays": 7}`, auth: true}); rr.Code != http.StatusOK {
+		t.Fatalf("set rule: got %d %s", rr.Code, rr.Body.String())
+	}
+	wantProblem(t, serve(h, request{method: "PUT", path: "/v1/rules/eggs/", body: `{"openedDays": -1}`, auth: true}),
+		http.StatusBadRequest, problem.CodeValidation)
+
+	var rules []groceryItemStore.ShelfLifeRule
+	decode(t, serve(h, request{method: "GET", path: "/v1/rules/"}), &rules)
+	if len(rules) != 1 || rules[0].Category != "milk" || rules[0].OpenedDays != 7 {
+		t.Errorf("got rules %+v, want the milk rule", rules)
+	}
+
+	if rr := serve(h, request{method: "POST", path: fmt.Sprintf("/v1/food/%d/open/", id), auth: true}); rr.Code != http.StatusOK {
+		t.Fatalf("open: got %d %s", rr.Code, rr.Body.String())
+	}
+	food, _ := server.groceryItemStore.GetFood(context.Background(), id)
+	if food.Opened == nil || !food.EffectiveExpiration.Before(label) {
+		t.Errorf("got %+v, want opened milk expiring within a week", food)
+	}
+
+	if rr := serve(h, request{method: "DELETE", path: "/v1/rules/milk/", auth: true}); rr.Code != http.StatusOK {
+		t.Errorf("delete rule: got %d", rr.Code)
+	}
+	wantProblem(t, serve(h, request{method: "DELETE", path: "/v1/rules/milk/", auth: true}), http.StatusNotFound, problem.CodeNotFound)
+}
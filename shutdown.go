@@ -0,0 +1,54 @@
+// Graceful shutdown of the server.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// shutdown stops srv gracefully. It stops accepting connections, ends the
+// event streams, subscriptions and WebSockets, and waits for the requests in
+// flight to finish until ctx is done; connections still open then are closed.
+// Afterwards it stops the background work with stopBackground, closes the
+// store and waits for the webhook deliveries under way.
+func (fs *foodServer) shutdown(ctx context.Context, srv *http.Server, stopBackground func()) error {
+	var errs []error
+
+	// Streams never finish on their own; closing the feed ends them.
+	srv.RegisterOnShutdown(fs.feed.Close)
+	if err := srv.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("draining connections: %w", err))
+		srv.Close()
+	}
+	if err := wait(ctx, &fs.wsConns); err != nil {
+		errs = append(errs, fmt.Errorf("closing websockets: %w", err))
+	}
+	slog.Info("connections drained")
+
+	stopBackground()
+	if err := fs.groceryItemStore.Close(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("closing store: %w", err))
+	}
+	fs.webhooks.Close()
+	return errors.Join(errs...)
+}
+
+// wait waits for wg until ctx is done.
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestShutdown(t *testing.T) {
+	server, h := newTestServer(t)
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	streamEnded := make(chan struct{})
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+		}
+		close(streamEnded)
+	}()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	stopped := false
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.shutdown(ctx, ts.Config, func() { stopped = true }); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	select {
+	case <-streamEnded:
+	case <-time.After(time.Second):
+		t.Error("event stream still open after shutdown")
+	}
+	_, _, err = ws.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("websocket read after shutdown: got %v, want going away", err)
+	}
+	if !stopped {
+		t.Error("background work not stopped")
+	}
+	if _, err := http.Get(ts.URL + "/healthz"); err == nil {
+		t.Error("server still accepting connections")
+	}
+}
@@ -0,0 +1,144 @@
+// Versions of the REST API. Each version is served under its own path prefix,
+// with its own representation of food items; the unversioned routes of the
+// original API are still served as v1, but are deprecated.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+)
+
+// apiVersion is a version of the REST API.
+type apiVersion struct {
+	Prefix         string // path prefix such as "/v1"; empty for the unversioned routes
+	Representation int    // version of the representation of food items served
+
+	// Deprecated is when the version was deprecated and Sunset when it stops
+	// being served; both are zero for current versions. Responses of
+	// deprecated versions link to the same route under Successor.
+	Deprecated time.Time
+	Sunset     time.Time
+	Successor  string
+}
+
+// apiVersions lists the served versions of the API.
+var apiVersions = []apiVersion{
+	{
+		Representation: 1,
+		Deprecated:     time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+		Sunset:         time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC),
+		Successor:      "/v1",
+	},
+	{Prefix: "/v1", Representation: 1},
+	{Prefix: "/v2", Representation: 2},
+}
+
+type versionContextKey struct{}
+
+// requestVersion returns the representation version of the API version req was
+// routed to, which is 1 for routes outside the versioned API.
+func requestVersion(req *http.Request) int {
+	if v, ok := req.Context().Value(versionContextKey{}).(int); ok {
+		return v
+	}
+	return 1
+}
+
+// subrouter returns a router for the routes of v under router. Requests routed
+// to it carry v's representation version in their context and, if v is
+// deprecated, get Deprecation, Sunset and successor Link headers.
+func (v apiVersion) subrouter(router *mux.Router) *mux.Router {
+	sub := router.NewRoute().Subrouter()
+	if v.Prefix != "" {
+		sub = router.PathPrefix(v.Prefix).Subrouter()
+	}
+	sub.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !v.Deprecated.IsZero() {
+				// Deprecation is an RFC 9745 date, Sunset an RFC 8594 HTTP-date.
+				w.Header().Set("Deprecation", fmt.Sprintf("@%d", v.Deprecated.Unix()))
+				if !v.Sunset.IsZero() {
+					w.Header().Set("Sunset", v.Sunset.UTC().Format(http.TimeFormat))
+				}
+				if v.Successor != "" {
+					successor := v.Successor + strings.TrimPrefix(req.URL.Path, v.Prefix)
+					w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+				}
+			}
+			ctx := context.WithValue(req.Context(), versionContextKey{}, v.Representation)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	})
+	return sub
+}
+
+// foodV2 is the v2 representation of a food item. Compared to v1 it groups the
+// expiration dates and the storage state, and reports unassigned items with a
+// null location instead of leaving the field out.
+type foodV2 struct {
+	Id          int                        `json:"id"`
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	Ingredients []string                   `json:"ingredients"`
+	Category    string                     `json:"category"`
+	Nutrition   groceryItemStore.Nutrition `json:"nutrition"`
+	Expires     expiresV2                  `json:"expires"`
+	Storage     storageV2                  `json:"storage"`
+}
+
+// expiresV2 holds the expiration printed on the label and the one computed by
+// the item's shelf-life rule.
+type expiresV2 struct {
+	Label     time.Time `json:"label"`
+	Effective time.Time `json:"effective"`
+}
+
+type storageV2 struct {
+	Location *int       `json:"location"` // null if unassigned
+	Opened   *time.Time `json:"opened"`   // null while sealed
+}
+
+func newFoodV2(food groceryItemStore.FoodItem) foodV2 {
+	v2 := foodV2{
+		Id:          food.Id,
+		Name:        food.Name,
+		Description: food.Description,
+		Ingredients: food.Ingredients,
+		Category:    food.Category,
+		Nutrition:   food.Nutrition,
+		Expires:     expiresV2{Label: food.Expiration, Effective: food.EffectiveExpiration},
+		Storage:     storageV2{Opened: food.Opened},
+	}
+	if food.Location != 0 {
+		location := food.Location
+		v2.Storage.Location = &location
+	}
+	return v2
+}
+
+// represent converts the response value v to representation version; values
+// that don't differ between versions are returned as they are.
+func represent(version int, v interface{}) interface{} {
+	if version < 2 {
+		return v
+	}
+	switch v := v.(type) {
+	case groceryItemStore.FoodItem:
+		return newFoodV2(v)
+	case []groceryItemStore.FoodItem:
+		foods := make([]foodV2, len(v))
+		for i, food := range v {
+			foods[i] = newFoodV2(food)
+		}
+		return foods
+	}
+	return v
+}
@@ -0,0 +1,136 @@
+// Handlers managing webhook subscriptions, their delivery log and the
+// dead-letter queue.
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/diorchen/rest-server/internal/middleware"
+	"github.com/diorchen/rest-server/internal/problem"
+	"github.com/diorchen/rest-server/internal/webhook"
+)
+
+// requestWebhook is the payload for subscribing a webhook.
+type requestWebhook struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// responseWebhook is the response to subscribing a webhook. The secret is only
+// ever returned here, so the subscriber can verify signatures.
+type responseWebhook struct {
+	Id     int    `json:"id"`
+	Secret string `json:"secret"`
+}
+
+func (fs *foodServer) createWebhookHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling webhook creation")
+
+	var rw requestWebhook
+	if !decodeJSON(w, req, &rw) {
+		return
+	}
+	if rw.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			renderError(w, req, err)
+			return
+		}
+		rw.Secret = hex.EncodeToString(b)
+	}
+
+	id, err := fs.webhooks.Subscribe(rw.URL, rw.Secret, rw.Events)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	renderJSON(w, responseWebhook{Id: id, Secret: rw.Secret})
+}
+
+func (fs *foodServer) getAllWebhooksHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling get all webhooks")
+	renderJSON(w, fs.webhooks.GetAllSubscriptions())
+}
+
+func (fs *foodServer) getWebhookHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling get webhook")
+	id, ok := pathInt(w, req, "id")
+	if !ok {
+		return
+	}
+
+	sub, err := fs.webhooks.GetSubscription(id)
+	if err != nil {
+		renderProblem(w, req, http.StatusNotFound, problem.CodeNotFound, err.Error())
+		return
+	}
+	renderJSON(w, sub)
+}
+
+func (fs *foodServer) deleteWebhookHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling deletion of webhook")
+	id, ok := pathInt(w, req, "id")
+	if !ok {
+		return
+	}
+
+	if err := fs.webhooks.Unsubscribe(id); err != nil {
+		renderProblem(w, req, http.StatusNotFound, problem.CodeNotFound, err.Error())
+		return
+	}
+}
+
+func (fs *foodServer) webhookDeliveriesHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling webhook deliveries")
+	id, ok := pathInt(w, req, "id")
+	if !ok {
+		return
+	}
+
+	deliveries, err := fs.webhooks.GetDeliveries(id)
+	if err != nil {
+		renderProblem(w, req, http.StatusNotFound, problem.CodeNotFound, err.Error())
+		return
+	}
+	renderJSON(w, deliveries)
+}
+
+func (fs *foodServer) getDeadLettersHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling get webhook dead letters")
+	renderJSON(w, fs.webhooks.GetDeadLetters())
+}
+
+func (fs *foodServer) retryDeadLetterHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling webhook dead letter retry")
+	id, ok := pathInt(w, req, "id")
+	if !ok {
+		return
+	}
+
+	if err := fs.webhooks.RetryDeadLetter(id); errors.Is(err, webhook.ErrBacklogFull) {
+		renderProblem(w, req, http.StatusConflict, problem.CodeConflict, err.Error())
+		return
+	} else if err != nil {
+		renderProblem(w, req, http.StatusNotFound, problem.CodeNotFound, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (fs *foodServer) deleteDeadLetterHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling deletion of webhook dead letter")
+	id, ok := pathInt(w, req, "id")
+	if !ok {
+		return
+	}
+
+	if err := fs.webhooks.DeleteDeadLetter(id); err != nil {
+		renderProblem(w, req, http.StatusNotFound, problem.CodeNotFound, err.Error())
+		return
+	}
+}
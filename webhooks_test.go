@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/diorchen/rest-server/internal/problem"
+	"github.com/diorchen/rest-server/internal/webhook"
+)
+
+func TestWebhookHandlers(t *testing.T) {
+	_, h := newTestServer(t)
+
+	rr := serve(h, request{method: "POST", path: "/v1/webhooks/", body: `{"url": "http://localhost:9/hook", "events": ["food.created"]}`, auth: true})
+	var created responseWebhook
+	decode(t, rr, &created)
+	if created.Secret == "" {
+		t.Error("got no generated secret")
+	}
+	for _, body := range []string{`{"url": ""}`, `{"url": "/hook"}`, `{"url": "ftp://localhost/hook"}`, `{"url": "http://localhost:9/hook", "events": ["food.eaten"]}`} {
+		wantProblem(t, serve(h, request{method: "POST", path: "/v1/webhooks/", body: body, auth: true}),
+			http.StatusBadRequest, problem.CodeValidation)
+	}
+
+	path := fmt.Sprintf("/v1/webhooks/%d/", created.Id)
+	var sub webhook.Subscription
+	decode(t, serve(h, request{method: "GET", path: path, auth: true}), &sub)
+	if sub.URL != "http://localhost:9/hook" || len(sub.Events) != 1 {
+		t.Errorf("got %+v", sub)
+	}
+
+	if rr := serve(h, request{method: "DELETE", path: path, auth: true}); rr.Code != http.StatusOK {
+		t.Errorf("delete: got %d", rr.Code)
+	}
+	wantProblem(t, serve(h, request{method: "GET", path: path, auth: true}), http.StatusNotFound, problem.CodeNotFound)
+}
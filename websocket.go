@@ -0,0 +1,284 @@
+// WebSocket API streaming live diffs of filtered views over the store.
+//
+// Clients send {"type": "subscribe", "id": "<name>", "filter": {...}} to open a
+// view, receive a "snapshot" of the matching items and then a "diff" whenever
+// an item starts matching, changes or stops matching. {"type": "unsubscribe",
+// "id": "<name>"} closes the view. Up to wsMaxViews views can share one
+// connection.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/diorchen/rest-server/internal/groceryItemStore"
+	"github.com/diorchen/rest-server/internal/middleware"
+	"github.com/diorchen/rest-server/internal/problem"
+	"github.com/diorchen/rest-server/internal/watch"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteWait   = 10 * time.Second // time allowed to write a message
+	wsPongWait    = 60 * time.Second // time allowed between pongs from the client
+	wsPingPeriod  = 50 * time.Second // must be less than wsPongWait
+	wsMaxMessage  = 4096             // maximum size of a client message
+	wsQueueSize   = 256              // outgoing messages buffered per connection
+	wsRefreshTime = time.Minute      // how often time dependent views are re-evaluated
+	wsMaxViews    = 32               // open views per connection
+)
+
+// upgrader is copied by wsHandler, which sets the origin check of the server.
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	Error: func(w http.ResponseWriter, req *http.Request, status int, reason error) {
+		renderProblem(w, req, status, problem.CodeBadRequest, reason.Error())
+	},
+}
+
+// wsRequest is a message from the client.
+type wsRequest struct {
+	Type   string       `json:"type"` // "subscribe" or "unsubscribe"
+	Id     string       `json:"id"`
+	Filter watch.Filter `json:"filter"`
+}
+
+// wsMessage is a message to the client.
+type wsMessage struct {
+	Type  string                      `json:"type"` // "snapshot", "diff", "unsubscribed" or "error"
+	Id    string                      `json:"id,omitempty"`
+	Items []groceryItemStore.FoodItem `json:"items,omitempty"`
+	Diff  *watch.Diff                 `json:"diff,omitempty"`
+	Error string                      `json:"error,omitempty"`
+}
+
+// wsConn is a single WebSocket client. Its views are only touched by the run
+// loop; reading and writing the socket happen in their own goroutines.
+type wsConn struct {
+	fs    *foodServer
+	conn  *websocket.Conn
+	views map[string]*watch.View
+
+	in  chan wsRequest
+	out chan wsMessage
+
+	// lagging is set when out was full and messages were dropped. The views
+	// are then resent as snapshots once the client has caught up.
+	lagging bool
+
+	// closeCode is sent to the client when the connection is closed by the
+	// server; it's set by the run loop before out is closed.
+	closeCode int
+}
+
+func (fs *foodServer) wsHandler(w http.ResponseWriter, req *http.Request) {
+	middleware.Logger(req.Context()).Debug("handling websocket")
+
+	u := upgrader
+	u.CheckOrigin = fs.checkOrigin
+	conn, err := u.Upgrade(w, req, nil)
+	if err != nil {
+		// Upgrade already replied with an error.
+		return
+	}
+	// The server doesn't track hijacked connections; shutdown waits for
+	// them here.
+	fs.wsConns.Add(1)
+	defer fs.wsConns.Done()
+
+	c := &wsConn{
+		fs:        fs,
+		conn:      conn,
+		views:     make(map[string]*watch.View),
+		in:        make(chan wsRequest),
+		out:       make(chan wsMessage, wsQueueSize),
+		closeCode: websocket.CloseNormalClosure,
+	}
+	done := make(chan struct{})
+	go c.readLoop(done)
+	go c.writeLoop()
+	c.run(req.Context(), done)
+	close(c.out)
+
+	// Unblock readLoop until the client has seen the close message and gone.
+	for {
+		select {
+		case <-c.in:
+		case <-done:
+			return
+		}
+	}
+}
+
+// run owns the views: it handles client requests and store events until the
+// connection is done or the server shuts down.
+func (c *wsConn) run(ctx context.Context, done chan struct{}) {
+	sub, _, _ := c.fs.feed.Subscribe(0, wsQueueSize)
+	defer func() { sub.Close() }()
+
+	refresh := time.NewTicker(wsRefreshTime)
+	defer refresh.Stop()
+	catchUp := time.NewTicker(time.Second)
+	defer catchUp.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+
+		case r := <-c.in:
+			c.handleRequest(ctx, r)
+
+		case ev, ok := <-sub.C:
+			if !ok && c.fs.feed.Closed() {
+				c.closeCode = websocket.CloseGoingAway
+				return
+			}
+			if !ok {
+				// The feed dropped us; start over with fresh snapshots.
+				sub, _, _ = c.fs.feed.Subscribe(0, wsQueueSize)
+				c.lagging = true
+				continue
+			}
+			if c.lagging {
+				continue
+			}
+			now := time.Now()
+			for id, v := range c.views {
+				if d, ok := v.Apply(ev, now); ok {
+					c.send(wsMessage{Type: "diff", Id: id, Diff: &d})
+				}
+			}
+
+		case <-refresh.C:
+			if c.lagging {
+				continue
+			}
+			var items []groceryItemStore.FoodItem
+			now := time.Now()
+			for id, v := range c.views {
+				if !v.Filter.TimeDependent() {
+					continue
+				}
+				if items == nil {
+					var err error
+					if items, err = c.fs.groceryItemStore.GetAllFood(ctx); err != nil {
+						return // the request was canceled
+					}
+				}
+				for _, d := range v.Refresh(items, now) {
+					d := d
+					c.send(wsMessage{Type: "diff", Id: id, Diff: &d})
+				}
+			}
+
+		case <-catchUp.C:
+			if c.lagging && len(c.out) < wsQueueSize/2 {
+				c.lagging = false
+				items, err := c.fs.groceryItemStore.GetAllFood(ctx)
+				if err != nil {
+					return // the request was canceled
+				}
+				for id, v := range c.views {
+					c.send(wsMessage{Type: "snapshot", Id: id, Items: v.Reset(items, time.Now())})
+				}
+			}
+		}
+	}
+}
+
+func (c *wsConn) handleRequest(ctx context.Context, r wsRequest) {
+	switch r.Type {
+	case "subscribe":
+		if err := r.Filter.Validate(); err != nil {
+			c.send(wsMessage{Type: "error", Id: r.Id, Error: err.Error()})
+			return
+		}
+		if _, ok := c.views[r.Id]; !ok && len(c.views) >= wsMaxViews {
+			c.send(wsMessage{Type: "error", Id: r.Id, Error: fmt.Sprintf("at most %d views per connection", wsMaxViews)})
+			return
+		}
+		all, err := c.fs.groceryItemStore.GetAllFood(ctx)
+		if err != nil {
+			c.send(wsMessage{Type: "error", Id: r.Id, Error: err.Error()})
+			return
+		}
+		v, items := watch.NewView(r.Filter, all, time.Now())
+		c.views[r.Id] = v
+		c.send(wsMessage{Type: "snapshot", Id: r.Id, Items: items})
+	case "unsubscribe":
+		delete(c.views, r.Id)
+		c.send(wsMessage{Type: "unsubscribed", Id: r.Id})
+	default:
+		c.send(wsMessage{Type: "error", Id: r.Id, Error: "expect message type subscribe or unsubscribe, got " + r.Type})
+	}
+}
+
+// send queues msg without blocking. If the client can't keep up, messages are
+// dropped and the connection marked as lagging.
+func (c *wsConn) send(msg wsMessage) {
+	if c.lagging {
+		return
+	}
+	select {
+	case c.out <- msg:
+	default:
+		c.lagging = true
+	}
+}
+
+// readLoop passes client requests to the run loop and closes done when the
+// client goes away.
+func (c *wsConn) readLoop(done chan struct{}) {
+	defer close(done)
+
+	c.conn.SetReadLimit(wsMaxMessage)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		var r wsRequest
+		if err := c.conn.ReadJSON(&r); err != nil {
+			if _, ok := err.(*websocket.CloseError); !ok {
+				slog.Warn("websocket read", "error", err)
+			}
+			return
+		}
+		c.in <- r
+	}
+}
+
+// writeLoop writes queued messages and pings to the client until out is
+// closed.
+func (c *wsConn) writeLoop() {
+	ping := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ping.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-c.out:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(c.closeCode, ""))
+				return
+			}
+			if err := c.conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ping.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/diorchen/rest-server/internal/config"
+	"github.com/diorchen/rest-server/internal/cors"
+)
+
+func TestWebSocketOrigin(t *testing.T) {
+	server, h := newTestServer(t)
+	cfg := config.Default().CORS
+	cfg.AllowedOrigins = []string{"https://app.example.com"}
+	server.checkOrigin = cors.CheckOrigin(cfg)
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"", true},
+		{ts.URL, true},
+		{"https://app.example.com", true},
+		{"https://evil.example.com", false},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.origin != "" {
+			header.Set("Origin", tt.origin)
+		}
+		ws, resp, err := websocket.DefaultDialer.Dial(url, header)
+		if tt.allowed {
+			if err != nil {
+				t.Errorf("origin %q: %v", tt.origin, err)
+				continue
+			}
+			ws.Close()
+		} else if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+			t.Errorf("origin %q: got %v, want 403", tt.origin, err)
+		}
+	}
+}
+
+func TestWebSocketMaxViews(t *testing.T) {
+	_, h := newTestServer(t)
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	for i := 0; i <= wsMaxViews; i++ {
+		if err := ws.WriteJSON(wsRequest{Type: "subscribe", Id: fmt.Sprint(i)}); err != nil {
+			t.Fatal(err)
+		}
+		var msg wsMessage
+		if err := ws.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		want := "snapshot"
+		if i == wsMaxViews {
+			want = "error"
+		}
+		if msg.Type != want {
+			t.Fatalf("view %d: got %+v, want %s", i, msg, want)
+		}
+	}
+
+	// Replacing an open view is still allowed.
+	ws.WriteJSON(wsRequest{Type: "subscribe", Id: "0"})
+	var msg wsMessage
+	if err := ws.ReadJSON(&msg); err != nil || msg.Type != "snapshot" {
+		t.Errorf("got %+v, %v, want a snapshot", msg, err)
+	}
+}